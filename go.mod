@@ -5,9 +5,9 @@ go 1.15
 require (
 	github.com/ghodss/yaml v1.0.0
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
-	github.com/peterbourgon/mergemap v0.0.0-20130613134717-e21c03b7a721
-	github.com/santhosh-tekuri/jsonschema v1.2.4
+	github.com/santhosh-tekuri/jsonschema/v5 v5.2.0
 	github.com/sirupsen/logrus v1.4.2
 	golang.org/x/sys v0.0.0-20190910064555-bbd175535a8b // indirect
 	gopkg.in/yaml.v2 v2.2.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
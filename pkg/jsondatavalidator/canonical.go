@@ -0,0 +1,192 @@
+package jsondatavalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// CanonicalizeJSON returns the RFC 8785 JSON Canonicalization Scheme (JCS)
+// serialization of a json buffer, i.e; without whitespace, with the keys of
+// each object sorted by their UTF-16 code units, with strings minimally
+// escaped and with numbers formatted as ECMAScript does. Equal json values
+// have the same canonical serialization, that can be hashed.
+func CanonicalizeJSON(jsonval []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(jsonval))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &UnmarshalError{Err: fmt.Errorf("unexpected data after the json value")}
+	}
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalString returns the canonical serialization of a decoded json value
+func canonicalString(v interface{}) string {
+	var buf bytes.Buffer
+	_ = writeCanonical(&buf, v)
+	return buf.String()
+}
+
+// writeCanonical writes the canonical serialization of a decoded json value
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch c := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(c))
+	case json.Number:
+		f, err := strconv.ParseFloat(string(c), 64)
+		if err != nil {
+			return &UnmarshalError{Err: err}
+		}
+		s, err := canonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case float64:
+		s, err := canonicalNumber(c)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case string:
+		writeCanonicalString(buf, c)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range c {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(c))
+		for k := range c {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, c[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected json value of type %T", v)
+	}
+	return nil
+}
+
+// lessUTF16 compares two strings by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// writeCanonicalString writes a json string, escaping only the quotation
+// mark, the reverse solidus and the control characters
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalNumber formats a number as the ECMAScript Number.prototype.toString
+// does, for e.g; 1e+21, 0.000001 and 1e-7
+func canonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("%v can not be serialized as json", f)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	// the shortest digits that round trip, and the exponent of the first one
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp := e[:strings.IndexByte(e, 'e')], e[strings.IndexByte(e, 'e')+1:]
+	digits := strings.Replace(mantissa, ".", "", 1)
+	x, _ := strconv.Atoi(exp)
+	k, n := len(digits), x+1
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+	expSign := "+"
+	if n-1 < 0 {
+		expSign = "-"
+	}
+	if k == 1 {
+		return sign + digits + "e" + expSign + strconv.Itoa(abs(n-1)), nil
+	}
+	return sign + digits[:1] + "." + digits[1:] + "e" + expSign + strconv.Itoa(abs(n-1)), nil
+}
+
+// abs returns the absolute value of i
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,105 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	testTable := []struct {
+		description    string
+		jsonval        string
+		expectedOutput string
+	}{
+		{"RFC 8785 example",
+			`{"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001], "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/", "literals": [null, true, false]}`,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`},
+		{"Keys sorted by UTF-16 code units",
+			`{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\ud83d\ude00": 5, "\u0080": 6, "\u00f6": 7}`,
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"\u00f6\":7,\"\u20ac\":1,\"\U0001F600\":5,\"\ufb33\":3}"},
+		{"Numbers", `[0, -0, 1e21, 1e20, 0.000001, 1e-7, 5e-324, 1.7976931348623157e308, -12.5, 100]`,
+			`[0,0,1e+21,100000000000000000000,0.000001,1e-7,5e-324,1.7976931348623157e+308,-12.5,100]`},
+		{"No HTML escaping", `{"pattern": "<a&b>"}`, `{"pattern":"<a&b>"}`},
+		{"Trailing whitespace", "{\"a\": 1}\n\t ", `{"a":1}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			out, err := jsondatavalidator.CanonicalizeJSON([]byte(tdr.jsonval))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(string(out))
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s", tdr.expectedOutput)
+			}
+		})
+	}
+
+	for _, jsonval := range []string{`{"key":`, `{} {}`, `{} x`, `{}}`, `{"a":1} ]`} {
+		if _, err := jsondatavalidator.CanonicalizeJSON([]byte(jsonval)); !errors.Is(err, jsondatavalidator.ErrUnmarshal) {
+			t.Errorf("expected %v for %s, got %v", jsondatavalidator.ErrUnmarshal, jsonval, err)
+		}
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateIsDeterministic(t *testing.T) {
+	templates := [][]byte{
+		[]byte("vm:\n  vcpus: $vcpus\n  memory: $memory\n"),
+		[]byte("vm:\n  memory: $memory\n  vcpus: $vcpus\n"),
+	}
+	var expected string
+	for i, template := range templates {
+		for j := 0; j < 5; j++ {
+			r, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(template, testJSONNonParamSchema,
+				testInputParamJSONSchema, []string{"vm_id", "name"}, `\$(.*)`, jsondatavalidator.WithCanonicalJSON())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i == 0 && j == 0 {
+				t.Log(string(r))
+				expected = string(r)
+			} else if string(r) != expected {
+				t.Errorf("expected %s, got %s", expected, r)
+			}
+		}
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateRequiredSection(t *testing.T) {
+	var testTemplate = []byte("vm:\n  vcpus: $vcpus\n  name: $name\n  memory: $vcpus\n")
+	testTable := []struct {
+		description      string
+		keysToAdd        []string
+		expectedRequired []string
+	}{
+		{"No keys added", nil, []string{"name", "vcpus"}},
+		{"Keys added", []string{"vm_id"}, []string{"name", "vcpus", "vm_id"}},
+		{"Keys that are parameters", []string{"vm_id", "name"}, []string{"name", "vcpus", "vm_id"}},
+		{"Keys added twice", []string{"vm_id", "vm_id"}, []string{"name", "vcpus", "vm_id"}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			r, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(testTemplate, testJSONNonParamSchema,
+				testInputParamJSONSchema, tdr.keysToAdd, `\$(.*)`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var schema struct {
+				Required []string `json:"required"`
+			}
+			if err := json.Unmarshal(r, &schema); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tdr.expectedRequired, schema.Required) {
+				t.Errorf("expected %v, got %v", tdr.expectedRequired, schema.Required)
+			}
+		})
+	}
+}
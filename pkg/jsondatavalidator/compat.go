@@ -0,0 +1,435 @@
+package jsondatavalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ChangeClass classifies a difference between two versions of a schema
+type ChangeClass string
+
+const (
+	// ChangeAddedRequired is a parameter that is required by the new version
+	// and was not by the old one, either new or optional until then
+	ChangeAddedRequired ChangeClass = "addedRequired"
+	// ChangeRemovedParameter is a parameter of the old version that the new
+	// version does not have
+	ChangeRemovedParameter ChangeClass = "removedParameter"
+	// ChangeTightened is a constraint that rejects values the old version
+	// accepted, for e.g; a lower "maximum"
+	ChangeTightened ChangeClass = "tightened"
+	// ChangeLoosened is a constraint that accepts values the old version
+	// rejected, for e.g; a higher "maximum"
+	ChangeLoosened ChangeClass = "loosened"
+	// ChangeTypeChanged is a "type" that neither allows every type of the
+	// old version nor only types of the old version
+	ChangeTypeChanged ChangeClass = "typeChanged"
+)
+
+// Breaking returns true if a change of the class can make input that was
+// valid for the old version invalid for the new one
+func (c ChangeClass) Breaking() bool {
+	return c != ChangeLoosened
+}
+
+// TemplateChange is a difference between the inputParam schemas of two
+// versions of a parameterized template
+type TemplateChange struct {
+	Class ChangeClass `json:"class"`
+	// Parameter is the name of the parameter that changed, empty for a
+	// change of one of the "definitions" the parameters refer to
+	Parameter string `json:"parameter"`
+	// Definition is the name of the definition that changed, under the
+	// "definitions" of the inputParam schema
+	Definition string `json:"definition,omitempty"`
+	// Keyword is the keyword of the definition of the parameter that
+	// changed, if any, for e.g; "maximum"
+	Keyword string `json:"keyword,omitempty"`
+	Message string `json:"message"`
+}
+
+func (c TemplateChange) String() string {
+	subject := fmt.Sprintf("parameter %q", c.Parameter)
+	if c.Definition != "" {
+		subject = fmt.Sprintf("definition %q", c.Definition)
+	}
+	if c.Keyword != "" {
+		return fmt.Sprintf("%s: %s: %s: %s", c.Class, subject, c.Keyword, c.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", c.Class, subject, c.Message)
+}
+
+// CompatibilityReport is the outcome of CompareTemplates
+type CompatibilityReport struct {
+	// Changes are the differences between the versions, ordered by
+	// parameter name, then by the name of the definitions they refer to
+	Changes []TemplateChange `json:"changes"`
+}
+
+// Compatible returns true if none of the changes is breaking, i.e; if the
+// input of the callers of the old version is valid for the new version
+func (rep *CompatibilityReport) Compatible() bool {
+	return len(rep.Breaking()) == 0
+}
+
+// Breaking returns the changes that are breaking
+func (rep *CompatibilityReport) Breaking() []TemplateChange {
+	var changes []TemplateChange
+	for _, c := range rep.Changes {
+		if c.Class.Breaking() {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// TemplateVersion holds the arguments of
+// GenerateJSONSchemaFromParameterizedTemplate for a version of a
+// parameterized template
+type TemplateVersion struct {
+	ParameterizedJSON          []byte
+	NonParamDefineJSONBuf      []byte
+	InputParamSchemaJSONBuf    []byte
+	KeysToAddToRequiredSection []string
+	RegExpStr                  string
+	GenerateOptions            []GenerateOption
+}
+
+// CompareTemplates takes as arguments:
+// i) oldVersion: the released version of a parameterized template
+// ii) newVersion: the version that is to replace it
+// The function generates the inputParam schema of each version and returns
+// their differences, as CompareInputParamSchemas does.
+func CompareTemplates(oldVersion, newVersion *TemplateVersion) (*CompatibilityReport, error) {
+	log.Debug()
+	var schemas [2][]byte
+	for i, v := range []*TemplateVersion{oldVersion, newVersion} {
+		schema, err := GenerateJSONSchemaFromParameterizedTemplate(v.ParameterizedJSON, v.NonParamDefineJSONBuf,
+			v.InputParamSchemaJSONBuf, v.KeysToAddToRequiredSection, v.RegExpStr, v.GenerateOptions...)
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = schema
+	}
+	return CompareInputParamSchemas(schemas[0], schemas[1])
+}
+
+// CompareInputParamSchemas takes as arguments:
+// i) oldSchema: the inputParam schema of the released version of a template
+// ii) newSchema: the inputParam schema of the version that is to replace it
+// The function returns a change for each parameter that is added as
+// required, removed or no longer required, and for each keyword of the
+// definition of a parameter that is tightened, loosened or, for "type",
+// changed. A change in the definition of a parameter that is not one of
+// the keywords compared, for e.g; in an "allOf", is reported as tightened.
+// The "definitions" the parameters refer to with "$ref" are compared the
+// same way, after the parameters.
+func CompareInputParamSchemas(oldSchema, newSchema []byte) (*CompatibilityReport, error) {
+	log.Debug()
+	var oldDoc, newDoc map[string]interface{}
+	if err := json.Unmarshal(oldSchema, &oldDoc); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	if err := json.Unmarshal(newSchema, &newDoc); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	oldProps, _ := oldDoc[KeyProperties].(map[string]interface{})
+	newProps, _ := newDoc[KeyProperties].(map[string]interface{})
+	oldRequired, newRequired := requiredSet(oldDoc), requiredSet(newDoc)
+
+	names := make(map[string]interface{}, len(oldProps)+len(newProps))
+	for name := range oldProps {
+		names[name] = true
+	}
+	for name := range newProps {
+		names[name] = true
+	}
+	rep := &CompatibilityReport{Changes: make([]TemplateChange, 0)}
+	for _, name := range sortedKeys(names) {
+		oldDef, inOld := oldProps[name]
+		newDef, inNew := newProps[name]
+		change := func(class ChangeClass, keyword string, msg string) {
+			rep.Changes = append(rep.Changes, TemplateChange{Class: class, Parameter: name, Keyword: keyword, Message: msg})
+		}
+		switch {
+		case !inNew:
+			change(ChangeRemovedParameter, "", "parameter removed")
+			continue
+		case !inOld && newRequired[name]:
+			change(ChangeAddedRequired, "", "required parameter added")
+			continue
+		case !inOld:
+			change(ChangeLoosened, "", "optional parameter added")
+			continue
+		case newRequired[name] && !oldRequired[name]:
+			change(ChangeAddedRequired, "", "parameter is now required")
+		case oldRequired[name] && !newRequired[name]:
+			change(ChangeLoosened, "", "parameter is no longer required")
+		}
+		compareDefinitions(oldDef, newDef, change)
+	}
+	// a definition added or removed is referred to by a "$ref" that
+	// changed, and is reported with the definition that holds it
+	oldDefs, _ := oldDoc[KeyDefinitions].(map[string]interface{})
+	newDefs, _ := newDoc[KeyDefinitions].(map[string]interface{})
+	for _, name := range sortedKeys(oldDefs) {
+		newDef, ok := newDefs[name]
+		if !ok {
+			continue
+		}
+		compareDefinitions(oldDefs[name], newDef, func(class ChangeClass, keyword string, msg string) {
+			rep.Changes = append(rep.Changes, TemplateChange{Class: class, Definition: name, Keyword: keyword, Message: msg})
+		})
+	}
+	log.WithFields(log.Fields{"Changes": rep.Changes}).Debug()
+	return rep, nil
+}
+
+// compareDefinitions calls change for each keyword of two versions of a
+// definition that is tightened, loosened or, for "type", changed, and once
+// for the other keywords if they differ
+func compareDefinitions(oldDef, newDef interface{}, change func(class ChangeClass, keyword string, msg string)) {
+	o, _ := oldDef.(map[string]interface{})
+	n, _ := newDef.(map[string]interface{})
+	for _, c := range compareConstraints(o, n) {
+		change(c.class, c.keyword, c.message)
+	}
+	if canonicalString(otherKeywords(o)) != canonicalString(otherKeywords(n)) {
+		change(ChangeTightened, "", "definition changed")
+	}
+}
+
+// requiredSet returns the names in the "required" list of a schema
+func requiredSet(schema map[string]interface{}) map[string]bool {
+	set := make(map[string]bool)
+	if req, ok := schema[KeyRequired].([]interface{}); ok {
+		for _, name := range req {
+			if s, ok := name.(string); ok {
+				set[s] = true
+			}
+		}
+	}
+	return set
+}
+
+// comparedKeywords are the keywords compared by compareConstraints, and
+// the annotations, that do not constrain values
+var comparedKeywords = []string{
+	"type", "enum", "const", "minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum",
+	"minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties",
+	"multipleOf", "uniqueItems", "pattern", "format",
+	"title", "description", "default", "examples", "$comment",
+}
+
+// otherKeywords returns a copy of a definition without the comparedKeywords
+func otherKeywords(def map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(def))
+	for k, v := range def {
+		c[k] = v
+	}
+	for _, kw := range comparedKeywords {
+		delete(c, kw)
+	}
+	return c
+}
+
+// valueChange describes the change of the value of a keyword
+func valueChange(oldVal, newVal interface{}, oldOk, newOk bool) string {
+	switch {
+	case !oldOk:
+		return fmt.Sprintf("added with %s", canonicalString(newVal))
+	case !newOk:
+		return fmt.Sprintf("removed, was %s", canonicalString(oldVal))
+	}
+	return fmt.Sprintf("changed from %s to %s", canonicalString(oldVal), canonicalString(newVal))
+}
+
+// constraintChange is a change of a keyword of a definition
+type constraintChange struct {
+	keyword string
+	class   ChangeClass
+	message string
+	// uncertain is true if the class is a guess, for e.g; a changed "pattern"
+	uncertain bool
+}
+
+// compareConstraints returns the changes of the keywords, at the top of
+// two versions of a definition, that constrain a value on their own: the
+// "type", the allowed values, the numeric range, the lengths and sizes,
+// "multipleOf", "uniqueItems", "pattern" and "format"
+func compareConstraints(oldDef, newDef map[string]interface{}) []constraintChange {
+	var changes []constraintChange
+	change := func(keyword string, class ChangeClass, oldVal, newVal interface{}, oldOk, newOk bool) {
+		changes = append(changes, constraintChange{keyword: keyword, class: class,
+			message: valueChange(oldVal, newVal, oldOk, newOk)})
+	}
+	// guess appends a change whose class can not be proven
+	guess := func(keyword string, class ChangeClass, oldVal, newVal interface{}, oldOk, newOk bool) {
+		change(keyword, class, oldVal, newVal, oldOk, newOk)
+		changes[len(changes)-1].uncertain = true
+	}
+
+	oldTypes, newTypes := schemaTypes(oldDef), schemaTypes(newDef)
+	if canonicalString(oldDef["type"]) != canonicalString(newDef["type"]) {
+		_, oldOk := oldDef["type"]
+		_, newOk := newDef["type"]
+		widened, narrowed := typesSubset(oldTypes, newTypes), typesSubset(newTypes, oldTypes)
+		switch {
+		case widened && narrowed:
+		case widened:
+			change("type", ChangeLoosened, oldDef["type"], newDef["type"], oldOk, newOk)
+		case narrowed:
+			change("type", ChangeTightened, oldDef["type"], newDef["type"], oldOk, newOk)
+		default:
+			change("type", ChangeTypeChanged, oldDef["type"], newDef["type"], oldOk, newOk)
+		}
+	}
+
+	for _, kw := range []string{"enum", "const"} {
+		oldVal, oldOk := oldDef[kw]
+		newVal, newOk := newDef[kw]
+		if canonicalString(oldVal) == canonicalString(newVal) && oldOk == newOk {
+			continue
+		}
+		oldEnum, newEnum := schemaEnum(map[string]interface{}{kw: oldVal}), schemaEnum(map[string]interface{}{kw: newVal})
+		switch {
+		case enumSubset(oldEnum, oldOk, newEnum, newOk):
+			change(kw, ChangeLoosened, oldVal, newVal, oldOk, newOk)
+		case enumSubset(newEnum, newOk, oldEnum, oldOk):
+			change(kw, ChangeTightened, oldVal, newVal, oldOk, newOk)
+		default:
+			// values are both added and removed
+			guess(kw, ChangeTightened, oldVal, newVal, oldOk, newOk)
+		}
+	}
+
+	for _, r := range []struct {
+		kw, exclusiveKw string
+		start, dir      float64
+	}{
+		{"minimum", "exclusiveMinimum", math.Inf(-1), 1},
+		{"maximum", "exclusiveMaximum", math.Inf(1), -1},
+	} {
+		oldBound := bound{value: r.start}.tighter(oldDef, r.kw, r.exclusiveKw, r.dir)
+		newBound := bound{value: r.start}.tighter(newDef, r.kw, r.exclusiveKw, r.dir)
+		if oldBound == newBound {
+			continue
+		}
+		class := ChangeLoosened
+		if oldBound.tighten(newBound, r.dir) == newBound {
+			class = ChangeTightened
+		}
+		kw := r.kw
+		if !hasKey(oldDef, kw) && !hasKey(newDef, kw) {
+			kw = r.exclusiveKw
+		}
+		change(kw, class, oldDef[kw], newDef[kw], hasKey(oldDef, kw), hasKey(newDef, kw))
+	}
+
+	for _, l := range []struct {
+		kw    string
+		limit float64
+		dir   float64
+	}{
+		{"minLength", 0, 1}, {"minItems", 0, 1}, {"minProperties", 0, 1},
+		{"maxLength", math.Inf(1), -1}, {"maxItems", math.Inf(1), -1}, {"maxProperties", math.Inf(1), -1},
+	} {
+		oldVal, newVal := l.limit, l.limit
+		if v, ok := oldDef[l.kw].(float64); ok {
+			oldVal = v
+		}
+		if v, ok := newDef[l.kw].(float64); ok {
+			newVal = v
+		}
+		if oldVal == newVal {
+			continue
+		}
+		class := ChangeLoosened
+		if (newVal-oldVal)*l.dir > 0 {
+			class = ChangeTightened
+		}
+		change(l.kw, class, oldDef[l.kw], newDef[l.kw], hasKey(oldDef, l.kw), hasKey(newDef, l.kw))
+	}
+
+	if oldVal, newVal := oldDef["multipleOf"], newDef["multipleOf"]; canonicalString(oldVal) != canonicalString(newVal) {
+		o, oldOk := oldVal.(float64)
+		n, newOk := newVal.(float64)
+		switch {
+		case !newOk || (oldOk && n != 0 && o/n == math.Trunc(o/n)):
+			change("multipleOf", ChangeLoosened, oldVal, newVal, hasKey(oldDef, "multipleOf"), hasKey(newDef, "multipleOf"))
+		case !oldOk || (o != 0 && n/o == math.Trunc(n/o)):
+			change("multipleOf", ChangeTightened, oldVal, newVal, hasKey(oldDef, "multipleOf"), hasKey(newDef, "multipleOf"))
+		default:
+			guess("multipleOf", ChangeTightened, oldVal, newVal, true, true)
+		}
+	}
+
+	if oldVal, newVal := oldDef["uniqueItems"] == true, newDef["uniqueItems"] == true; oldVal != newVal {
+		class := ChangeLoosened
+		if newVal {
+			class = ChangeTightened
+		}
+		change("uniqueItems", class, oldVal, newVal, true, true)
+	}
+
+	for _, kw := range []string{"pattern", "format"} {
+		oldVal, oldOk := oldDef[kw]
+		newVal, newOk := newDef[kw]
+		if canonicalString(oldVal) == canonicalString(newVal) && oldOk == newOk {
+			continue
+		}
+		switch {
+		case !newOk:
+			change(kw, ChangeLoosened, oldVal, newVal, oldOk, newOk)
+		case !oldOk:
+			change(kw, ChangeTightened, oldVal, newVal, oldOk, newOk)
+		default:
+			// a different pattern can not be compared to the old one
+			guess(kw, ChangeTightened, oldVal, newVal, oldOk, newOk)
+		}
+	}
+	return changes
+}
+
+// hasKey returns true if the definition holds the keyword
+func hasKey(def map[string]interface{}, kw string) bool {
+	_, ok := def[kw]
+	return ok
+}
+
+// typesSubset returns true if every type of a is allowed by b, an integer
+// being a number. A nil set allows any type.
+func typesSubset(a, b map[string]bool) bool {
+	if b == nil {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	for t := range a {
+		if !b[t] && !(t == "integer" && b["number"]) {
+			return false
+		}
+	}
+	return true
+}
+
+// enumSubset returns true if every value of a is allowed by b. A set that
+// is not present allows any value.
+func enumSubset(a map[string]bool, aOk bool, b map[string]bool, bOk bool) bool {
+	if !bOk {
+		return true
+	}
+	if !aOk {
+		return false
+	}
+	for v := range a {
+		if !b[v] {
+			return false
+		}
+	}
+	return true
+}
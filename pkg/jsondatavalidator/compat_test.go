@@ -0,0 +1,172 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestCompareTemplates(t *testing.T) {
+	var regExpStr = `\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`
+	var testTemplate = []byte("vm:\n  vcpus: $vcpus\n  memory: $memory|1024\n")
+	nonParamSchema := func(vcpus string) []byte {
+		return []byte(fmt.Sprintf(`{"vmDeviceDefine": {"vm": {"type": "object", "properties": {
+			"vcpus": %s,
+			"memory": {"type": "integer", "minimum": 512, "maximum": 16384},
+			"name": {"type": "string"}}}}}`, vcpus))
+	}
+	var testVcpus = `{"type": "integer", "minimum": 2, "maximum": 16}`
+	oldVersion := &jsondatavalidator.TemplateVersion{
+		ParameterizedJSON:       testTemplate,
+		NonParamDefineJSONBuf:   nonParamSchema(testVcpus),
+		InputParamSchemaJSONBuf: []byte(`{"inputParam": {"type": "object"}}`),
+		RegExpStr:               regExpStr,
+	}
+
+	testTable := []struct {
+		description        string
+		template           []byte
+		vcpus              string
+		expectedChanges    []string
+		expectedCompatible bool
+	}{
+		{"Same version", testTemplate, testVcpus, []string{}, true},
+		{"Required parameter added", []byte("vm:\n  vcpus: $vcpus\n  memory: $memory|1024\n  name: $name\n"), testVcpus,
+			[]string{`addedRequired: parameter "name": required parameter added`}, false},
+		{"Optional parameter added", []byte("vm:\n  vcpus: $vcpus\n  memory: $memory|1024\n  name: $name|vm\n"), testVcpus,
+			[]string{`loosened: parameter "name": optional parameter added`}, true},
+		{"Parameter removed", []byte("vm:\n  vcpus: $vcpus\n  memory: 1024\n"), testVcpus,
+			[]string{`removedParameter: parameter "memory": parameter removed`}, false},
+		{"Parameter now required", []byte("vm:\n  vcpus: $vcpus\n  memory: $memory\n"), testVcpus,
+			[]string{`addedRequired: parameter "memory": parameter is now required`}, false},
+		{"Range tightened", testTemplate, `{"type": "integer", "minimum": 2, "maximum": 8}`,
+			[]string{`tightened: parameter "vcpus": maximum: changed from 16 to 8`}, false},
+		{"Range loosened", testTemplate, `{"type": "integer", "minimum": 1, "maximum": 16}`,
+			[]string{`loosened: parameter "vcpus": minimum: changed from 2 to 1`}, true},
+		{"Type changed", testTemplate, `{"type": "string", "minimum": 2, "maximum": 16}`,
+			[]string{`typeChanged: parameter "vcpus": type: changed from "integer" to "string"`}, false},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			newVersion := *oldVersion
+			newVersion.ParameterizedJSON = tdr.template
+			newVersion.NonParamDefineJSONBuf = nonParamSchema(tdr.vcpus)
+			rep, err := jsondatavalidator.CompareTemplates(oldVersion, &newVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := make([]string, 0)
+			for _, c := range rep.Changes {
+				changes = append(changes, c.String())
+			}
+			if !reflect.DeepEqual(tdr.expectedChanges, changes) {
+				t.Errorf("expected %v, got %v", tdr.expectedChanges, changes)
+			}
+			if rep.Compatible() != tdr.expectedCompatible {
+				t.Errorf("expected compatible to be %v", tdr.expectedCompatible)
+			}
+		})
+	}
+}
+
+func TestCompareInputParamSchemas(t *testing.T) {
+	testTable := []struct {
+		description     string
+		oldDef          string
+		newDef          string
+		expectedChanges []string
+	}{
+		{"Annotations", `{"type": "integer", "default": 1}`, `{"type": "integer", "default": 2, "description": "count"}`, []string{}},
+		{"Type widened", `{"type": "integer"}`, `{"type": ["integer", "string"]}`,
+			[]string{`loosened: parameter "p": type: changed from "integer" to ["integer","string"]`}},
+		{"Type narrowed", `{"type": "number"}`, `{"type": "integer"}`,
+			[]string{`tightened: parameter "p": type: changed from "number" to "integer"`}},
+		{"Enum values removed", `{"enum": ["a", "b"]}`, `{"enum": ["a"]}`,
+			[]string{`tightened: parameter "p": enum: changed from ["a","b"] to ["a"]`}},
+		{"Enum values added", `{"enum": ["a"]}`, `{"enum": ["a", "b"]}`,
+			[]string{`loosened: parameter "p": enum: changed from ["a"] to ["a","b"]`}},
+		{"Exclusive bound", `{"exclusiveMaximum": 10}`, `{"maximum": 10}`,
+			[]string{`loosened: parameter "p": maximum: added with 10`}},
+		{"Length added", `{"type": "string"}`, `{"type": "string", "maxLength": 8}`,
+			[]string{`tightened: parameter "p": maxLength: added with 8`}},
+		{"Pattern removed", `{"pattern": "^a"}`, `{}`,
+			[]string{`loosened: parameter "p": pattern: removed, was "^a"`}},
+		{"Multiple of a divisor", `{"multipleOf": 4}`, `{"multipleOf": 2}`,
+			[]string{`loosened: parameter "p": multipleOf: changed from 4 to 2`}},
+		{"Other keywords", `{"allOf": [{"minimum": 1}]}`, `{"allOf": [{"minimum": 2}]}`,
+			[]string{`tightened: parameter "p": definition changed`}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			rep, err := jsondatavalidator.CompareInputParamSchemas(
+				[]byte(fmt.Sprintf(`{"properties": {"p": %s}, "required": ["p"]}`, tdr.oldDef)),
+				[]byte(fmt.Sprintf(`{"properties": {"p": %s}, "required": ["p"]}`, tdr.newDef)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := make([]string, 0)
+			for _, c := range rep.Changes {
+				changes = append(changes, c.String())
+			}
+			if !reflect.DeepEqual(tdr.expectedChanges, changes) {
+				t.Errorf("expected %v, got %v", tdr.expectedChanges, changes)
+			}
+		})
+	}
+}
+
+func TestCompareTemplatesWithDefinitions(t *testing.T) {
+	var regExpStr = `\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`
+	var testTemplate = []byte("vm:\n  vcpus: $vcpus\n")
+	nonParamSchema := func(vcpuCount string) []byte {
+		return []byte(fmt.Sprintf(`{"definitions": {"vcpuCount": %s},
+			"vmDeviceDefine": {"vm": {"type": "object", "properties": {
+			"vcpus": {"$ref": "#/definitions/vcpuCount", "description": "number of vcpus"}}}}}`, vcpuCount))
+	}
+	var testVcpuCount = `{"type": "integer", "minimum": 2, "maximum": 16}`
+	oldVersion := &jsondatavalidator.TemplateVersion{
+		ParameterizedJSON:       testTemplate,
+		NonParamDefineJSONBuf:   nonParamSchema(testVcpuCount),
+		InputParamSchemaJSONBuf: []byte(`{"inputParam": {"type": "object"}}`),
+		RegExpStr:               regExpStr,
+	}
+
+	testTable := []struct {
+		description        string
+		vcpuCount          string
+		expectedChanges    []string
+		expectedCompatible bool
+	}{
+		{"Same definition", testVcpuCount, []string{}, true},
+		{"Definition tightened", `{"type": "integer", "minimum": 2, "maximum": 8}`,
+			[]string{`tightened: definition "vcpuCount": maximum: changed from 16 to 8`}, false},
+		{"Definition loosened", `{"type": "integer", "minimum": 1, "maximum": 16}`,
+			[]string{`loosened: definition "vcpuCount": minimum: changed from 2 to 1`}, true},
+		{"Other keywords of the definition", `{"type": "integer", "minimum": 2, "maximum": 16, "not": {"const": 3}}`,
+			[]string{`tightened: definition "vcpuCount": definition changed`}, false},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			newVersion := *oldVersion
+			newVersion.NonParamDefineJSONBuf = nonParamSchema(tdr.vcpuCount)
+			rep, err := jsondatavalidator.CompareTemplates(oldVersion, &newVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := make([]string, 0)
+			for _, c := range rep.Changes {
+				changes = append(changes, c.String())
+			}
+			if !reflect.DeepEqual(tdr.expectedChanges, changes) {
+				t.Errorf("expected %v, got %v", tdr.expectedChanges, changes)
+			}
+			if rep.Compatible() != tdr.expectedCompatible {
+				t.Errorf("expected compatible to be %v", tdr.expectedCompatible)
+			}
+		})
+	}
+}
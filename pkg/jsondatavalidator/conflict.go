@@ -0,0 +1,186 @@
+package jsondatavalidator
+
+import "math"
+
+// parameterUse is the definition of a placeholder at one of the places a
+// parameter is used in a template
+type parameterUse struct {
+	ph  Placeholder
+	ptr string
+	def map[string]interface{}
+}
+
+// combineDefinitions returns the definition of a parameter used in several
+// places of a template: the definitions of its uses combined with "allOf",
+// leaving out duplicates
+func combineDefinitions(uses []parameterUse) map[string]interface{} {
+	var defs []interface{}
+	seen := make(map[string]bool)
+	for _, u := range uses {
+		if c := canonicalString(u.def); !seen[c] {
+			seen[c] = true
+			defs = append(defs, u.def)
+		}
+	}
+	switch len(defs) {
+	case 0:
+		return map[string]interface{}{}
+	case 1:
+		return defs[0].(map[string]interface{})
+	}
+	return map[string]interface{}{"allOf": defs}
+}
+
+// findConflicts returns a diagnostic for each constraint, of the "type",
+// "enum" and "const", the numeric range or the length, the definitions of
+// the uses of a parameter can not satisfy together. Only the keywords at
+// the top of each definition are compared.
+func findConflicts(name string, uses []parameterUse) []Diagnostic {
+	if len(uses) < 2 {
+		return nil
+	}
+	candidates := make([]string, 0, len(uses))
+	seen := make(map[string]bool)
+	for _, u := range uses {
+		if !seen[u.ptr] {
+			seen[u.ptr] = true
+			candidates = append(candidates, u.ptr)
+		}
+	}
+	var diags []Diagnostic
+	conflict := func(what string) {
+		diags = append(diags, Diagnostic{Code: DiagnosticConflict, Parameter: name,
+			Pointer: uses[0].ph.Pointer, Candidates: candidates,
+			Message: "the " + what + " of the definitions do not overlap"})
+	}
+
+	var types map[string]bool
+	var enum map[string]bool
+	lower := bound{value: math.Inf(-1)}
+	upper := bound{value: math.Inf(1)}
+	minLength, maxLength := 0.0, math.Inf(1)
+	for _, u := range uses {
+		if t := schemaTypes(u.def); t != nil {
+			types = intersectTypes(types, t)
+		}
+		if e := schemaEnum(u.def); e != nil {
+			enum = intersectEnum(enum, e)
+		}
+		lower = lower.tighter(u.def, "minimum", "exclusiveMinimum", 1)
+		upper = upper.tighter(u.def, "maximum", "exclusiveMaximum", -1)
+		if v, ok := u.def["minLength"].(float64); ok && v > minLength {
+			minLength = v
+		}
+		if v, ok := u.def["maxLength"].(float64); ok && v < maxLength {
+			maxLength = v
+		}
+	}
+	if types != nil && len(types) == 0 {
+		conflict("types")
+	}
+	if enum != nil && len(enum) == 0 {
+		conflict("allowed values")
+	}
+	if lower.value > upper.value || (lower.value == upper.value && (lower.exclusive || upper.exclusive)) {
+		conflict("ranges")
+	}
+	if minLength > maxLength {
+		conflict("lengths")
+	}
+	return diags
+}
+
+// bound is the lower or upper bound of a numeric range
+type bound struct {
+	value     float64
+	exclusive bool
+}
+
+// tighter returns the tighter of the bound and the bound set by the
+// keywords of a definition. dir is 1 for a lower bound and -1 for an
+// upper bound.
+func (b bound) tighter(def map[string]interface{}, kw string, exclusiveKw string, dir float64) bound {
+	if v, ok := def[kw].(float64); ok {
+		// draft 4 sets an exclusive bound with a boolean
+		exclusive, _ := def[exclusiveKw].(bool)
+		b = b.tighten(bound{value: v, exclusive: exclusive}, dir)
+	}
+	if v, ok := def[exclusiveKw].(float64); ok {
+		b = b.tighten(bound{value: v, exclusive: true}, dir)
+	}
+	return b
+}
+
+// tighten returns the tighter of two bounds
+func (b bound) tighten(o bound, dir float64) bound {
+	if o.value*dir > b.value*dir || (o.value == b.value && o.exclusive) {
+		return o
+	}
+	return b
+}
+
+// schemaTypes returns the types a definition allows, nil if it allows any
+func schemaTypes(def map[string]interface{}) map[string]bool {
+	switch t := def["type"].(type) {
+	case string:
+		return map[string]bool{t: true}
+	case []interface{}:
+		types := make(map[string]bool, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types[s] = true
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// intersectTypes returns the types allowed by both sets, an integer being a number
+func intersectTypes(a, b map[string]bool) map[string]bool {
+	if a == nil {
+		return b
+	}
+	types := make(map[string]bool)
+	for t := range a {
+		switch {
+		case b[t]:
+			types[t] = true
+		case t == "integer" && b["number"], t == "number" && b["integer"]:
+			types["integer"] = true
+		}
+	}
+	return types
+}
+
+// schemaEnum returns the canonical json of the values allowed by the
+// "enum" or "const" of a definition, nil if it has neither
+func schemaEnum(def map[string]interface{}) map[string]bool {
+	var values []interface{}
+	if c, ok := def["const"]; ok {
+		values = []interface{}{c}
+	} else if e, ok := def["enum"].([]interface{}); ok {
+		values = e
+	} else {
+		return nil
+	}
+	enum := make(map[string]bool, len(values))
+	for _, v := range values {
+		enum[canonicalString(v)] = true
+	}
+	return enum
+}
+
+// intersectEnum returns the values allowed by both sets
+func intersectEnum(a, b map[string]bool) map[string]bool {
+	if a == nil {
+		return b
+	}
+	enum := make(map[string]bool)
+	for v := range a {
+		if b[v] {
+			enum[v] = true
+		}
+	}
+	return enum
+}
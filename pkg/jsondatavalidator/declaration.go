@@ -0,0 +1,248 @@
+package jsondatavalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// jsonTypes are the types a placeholder can declare for its parameter
+var jsonTypes = []string{"string", "integer", "number", "boolean", "object", "array", "null"}
+
+// parameterSpec is the name of a parameter and what its placeholder
+// declares about it
+type parameterSpec struct {
+	name       string
+	typ        string
+	def        interface{}
+	hasDefault bool
+	optional   bool
+}
+
+// parseParameter returns the parameter of the text of a placeholder match.
+// The text may declare a default value and a type after the name,
+// in the forms "name|default", "name=default", "name:type" and
+// "name:type=default", for e.g; "memory|1024" or "memory:integer=1024".
+// A name that ends with "?", for e.g; "memory?" or "memory?:integer",
+// marks the parameter as optional.
+func parseParameter(text string) parameterSpec {
+	spec := parameterSpec{name: text}
+	if i := strings.Index(text, "|"); i >= 0 {
+		spec.name, spec.def, spec.hasDefault = text[:i], parseDefault(text[i+1:], ""), true
+	} else {
+		defText := ""
+		if i := strings.Index(text, "="); i >= 0 {
+			spec.name, spec.hasDefault, defText = text[:i], true, text[i+1:]
+		}
+		if i := strings.Index(spec.name, ":"); i >= 0 {
+			spec.name, spec.typ = spec.name[:i], spec.name[i+1:]
+		}
+		if spec.hasDefault {
+			spec.def = parseDefault(defText, spec.typ)
+		}
+	}
+	if strings.HasSuffix(spec.name, "?") {
+		spec.name, spec.optional = strings.TrimSuffix(spec.name, "?"), true
+	}
+	return spec
+}
+
+// parseDefault decodes the text of a default value as a yaml scalar, for
+// e.g; "1024" as a number, unless the declared type is "string"
+func parseDefault(text string, typ string) interface{} {
+	if typ == "string" {
+		return text
+	}
+	var v interface{}
+	if err := yamlv3.Unmarshal([]byte(text), &v); err != nil {
+		return text
+	}
+	// in the generic form of a decoded json document, i.e; numbers as float64
+	b, err := json.Marshal(v)
+	if err != nil {
+		return text
+	}
+	var d interface{}
+	_ = json.Unmarshal(b, &d)
+	return d
+}
+
+// jsonType returns the type of a value of a decoded json document
+func jsonType(v interface{}) string {
+	switch c := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if c == float64(int64(c)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// isJSONType returns true if typ names a json schema type
+func isJSONType(typ string) bool {
+	for _, t := range jsonTypes {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDeclarations returns a diagnostic if the type declared by the
+// placeholder is not allowed by the definition of the placeholder
+func checkDeclarations(ph Placeholder, def map[string]interface{}) []Diagnostic {
+	if ph.Type != "" && isJSONType(ph.Type) && !typeAllowed(ph.Type, def) {
+		return []Diagnostic{{Code: DiagnosticTypeMismatch, Parameter: ph.Name, Pointer: ph.Pointer,
+			Message: fmt.Sprintf("declared type %q is not allowed by the definition", ph.Type)}}
+	}
+	return nil
+}
+
+// typeAllowed returns true if a value of the type can satisfy the "type"
+// of the definition, and of the definitions it holds in an "allOf"
+func typeAllowed(typ string, def map[string]interface{}) bool {
+	if types := schemaTypes(def); types != nil && len(intersectTypes(types, map[string]bool{typ: true})) == 0 {
+		return false
+	}
+	if branches, ok := def["allOf"].([]interface{}); ok {
+		for _, b := range branches {
+			if m, ok := b.(map[string]interface{}); ok && !typeAllowed(typ, m) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// narrowType returns the "type" of a definition narrowed to a declared
+// type it allows, for e.g; "integer" for a declared "number" and an
+// "integer" definition
+func narrowType(typ string, def map[string]interface{}) string {
+	for t := range intersectTypes(schemaTypes(def), map[string]bool{typ: true}) {
+		return t
+	}
+	return typ
+}
+
+// defaultViolation returns why a default value is not valid against the
+// definition of its parameter, "" if it is. defs holds the "definitions"
+// the definition may refer to.
+func defaultViolation(value interface{}, def map[string]interface{}, defs map[string]interface{}) string {
+	if t := jsonType(value); !typeAllowed(t, def) {
+		return fmt.Sprintf("default value of type %q is not allowed by the definition", t)
+	}
+	doc := make(map[string]interface{}, len(def)+1)
+	for k, v := range def {
+		doc[k] = v
+	}
+	if len(defs) > 0 {
+		doc[KeyDefinitions] = defs
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return ""
+	}
+	// a definition that does not compile fails the generated schema anyway
+	v, err := NewValidator(bytes.NewReader(b), "default.json")
+	if err != nil {
+		return ""
+	}
+	res, err := v.validateInterface(normalizeValue(value))
+	if err != nil || res.Valid() {
+		return ""
+	}
+	return fmt.Sprintf("default value %s is not valid against the definition: %s",
+		canonicalString(value), res.Violations[len(res.Violations)-1].Message)
+}
+
+// applyDeclarations adds to the definition of a parameter the type and the
+// default value its placeholders declare, and returns a diagnostic for
+// each declaration that conflicts with the others. A declared type only
+// narrows the "type" of the definition, it is not added if the definition
+// does not allow it. A default value that is not valid against the
+// definition is reported and not added, and the placeholders that declare
+// it no longer have a default, i.e; the parameter is required. defs holds
+// the "definitions" the definition may refer to.
+func applyDeclarations(def map[string]interface{}, defs map[string]interface{}, name string,
+	placeholders []Placeholder) []Diagnostic {
+	var diags []Diagnostic
+	var declared *Placeholder
+	var defaulted *Placeholder
+	var rejected string
+	for i := range placeholders {
+		ph := &placeholders[i]
+		if ph.Name != name {
+			continue
+		}
+		if ph.Type != "" {
+			switch {
+			case !isJSONType(ph.Type):
+				diags = append(diags, Diagnostic{Code: DiagnosticTypeMismatch, Parameter: name,
+					Pointer: ph.Pointer, Message: fmt.Sprintf("unknown type %q", ph.Type)})
+			case declared == nil:
+				declared = ph
+				if typeAllowed(ph.Type, def) {
+					def["type"] = narrowType(ph.Type, def)
+				}
+			case declared.Type != ph.Type:
+				diags = append(diags, Diagnostic{Code: DiagnosticConflict, Parameter: name, Pointer: ph.Pointer,
+					Message: fmt.Sprintf("declared type %q differs from %q declared at %s", ph.Type, declared.Type, declared.Pointer)})
+			}
+		}
+		if ph.HasDefault {
+			switch {
+			case defaulted == nil:
+				defaulted = ph
+				if msg := defaultViolation(ph.Default, def, defs); msg != "" {
+					diags = append(diags, Diagnostic{Code: DiagnosticTypeMismatch, Parameter: name,
+						Pointer: ph.Pointer, Message: msg})
+					rejected = canonicalString(ph.Default)
+					ph.HasDefault = false
+				} else {
+					def["default"] = ph.Default
+				}
+			case rejected != "" && rejected == canonicalString(ph.Default):
+				ph.HasDefault = false
+			case canonicalString(defaulted.Default) != canonicalString(ph.Default):
+				diags = append(diags, Diagnostic{Code: DiagnosticConflict, Parameter: name, Pointer: ph.Pointer,
+					Message: fmt.Sprintf("default value differs from the one declared at %s", defaulted.Pointer)})
+			}
+		}
+	}
+	return diags
+}
+
+// isRequired returns true if a placeholder of the parameter needs a value,
+// i.e; is neither optional nor declares a default value
+func isRequired(name string, placeholders []Placeholder) bool {
+	for _, ph := range placeholders {
+		if ph.Name == name && !ph.HasDefault && !ph.Optional {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeValue returns a decoded yaml value in the generic form of a
+// decoded json document, i.e; with numbers as float64
+func normalizeValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var d interface{}
+	_ = json.Unmarshal(b, &d)
+	return d
+}
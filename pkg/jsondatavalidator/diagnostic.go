@@ -0,0 +1,148 @@
+package jsondatavalidator
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DiagnosticCode classifies a Diagnostic
+type DiagnosticCode string
+
+const (
+	// DiagnosticUnresolved is reported when no definition is found for a
+	// placeholder in the non parameterized schema
+	DiagnosticUnresolved DiagnosticCode = "unresolved"
+	// DiagnosticAmbiguous is reported when more than one definition is
+	// found for a placeholder in the non parameterized schema
+	DiagnosticAmbiguous DiagnosticCode = "ambiguous"
+	// DiagnosticNotAString is reported when a placeholder is embedded in a
+	// string whose definition does not accept strings
+	DiagnosticNotAString DiagnosticCode = "notAString"
+	// DiagnosticConflict is reported when a parameter used in several
+	// places of a template has definitions no value can satisfy together
+	DiagnosticConflict DiagnosticCode = "conflict"
+	// DiagnosticTypeMismatch is reported when the type a placeholder
+	// declares, or the type of its default value, is not allowed by its
+	// definition, or is not a json schema type
+	DiagnosticTypeMismatch DiagnosticCode = "typeMismatch"
+	// DiagnosticConstraintDropped is reported when a constraint of the
+	// string a placeholder is embedded in, for e.g; its "format", can not
+	// be checked against the value of the placeholder on its own
+	DiagnosticConstraintDropped DiagnosticCode = "constraintDropped"
+)
+
+// err returns the error a *DiagnosticError with a diagnostic of the code matches
+func (c DiagnosticCode) err() error {
+	switch c {
+	case DiagnosticUnresolved, DiagnosticAmbiguous:
+		return ErrUnresolvedParameter
+	case DiagnosticConflict:
+		return ErrParameterConflict
+	}
+	return ErrDefinitionMismatch
+}
+
+// Diagnostic is a problem found while generating the inputParam schema of a
+// parameterized template, that did not prevent the schema from being generated
+type Diagnostic struct {
+	Code DiagnosticCode `json:"code"`
+	// Parameter is the name of the parameter of the placeholder
+	Parameter string `json:"parameter"`
+	// Pointer is the json-pointer of the placeholder in the template
+	Pointer string `json:"pointer"`
+	// Candidates are the json-pointers of the definitions found in the non
+	// parameterized schema, when there is more than one or when they conflict
+	Candidates []string `json:"candidates,omitempty"`
+	Message    string   `json:"message"`
+}
+
+// String returns the diagnostic in the form
+// "<code>: parameter <name> at <pointer>: <message>"
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: parameter %q at %s: %s", d.Code, d.Parameter, d.Pointer, d.Message)
+}
+
+// GenerateOption configures GenerateJSONSchemaFromParameterizedTemplate
+type GenerateOption func(*generatorConfig)
+
+// generatorConfig holds the settings GenerateOptions apply to
+type generatorConfig struct {
+	strict    bool
+	canonical bool
+	syntax    PlaceholderSyntax
+	// overlays are applied, in order, to the generated inputParam schema
+	overlays []func(schema interface{}) (interface{}, error)
+	// profile is applied to the non parameterized schema
+	profile *Profile
+}
+
+// WithStrict makes the generation of the inputParam schema fail, with a
+// *DiagnosticError, when a diagnostic is found, for e.g; when the
+// definition of a placeholder is missing or ambiguous. Without it, such a
+// placeholder accepts any value.
+func WithStrict() GenerateOption {
+	return func(cfg *generatorConfig) {
+		cfg.strict = true
+	}
+}
+
+// WithCanonicalJSON makes the generated inputParam schema be serialized
+// with CanonicalizeJSON, for it to be hashed and cached
+func WithCanonicalJSON() GenerateOption {
+	return func(cfg *generatorConfig) {
+		cfg.canonical = true
+	}
+}
+
+// WithPlaceholderSyntax makes the placeholders of the template be found with
+// the syntax, for e.g; the one returned by
+// LookupPlaceholderSyntax(SyntaxDollarBrace), instead of the regExpStr
+// argument, that is then ignored
+func WithPlaceholderSyntax(syntax PlaceholderSyntax) GenerateOption {
+	return func(cfg *generatorConfig) {
+		cfg.syntax = syntax
+	}
+}
+
+// WithMergePatch applies an RFC 7396 JSON Merge Patch to the generated
+// inputParam schema, for e.g; {"properties": {"vcpus": {"maximum": 4}}}.
+// Overlays are applied in the order of their options, after the generated
+// properties and "required" list are merged into inputParamSchemaJSONBuf.
+func WithMergePatch(patchJSON []byte) GenerateOption {
+	return func(cfg *generatorConfig) {
+		cfg.overlays = append(cfg.overlays, func(schema interface{}) (interface{}, error) {
+			var patch interface{}
+			if err := json.Unmarshal(patchJSON, &patch); err != nil {
+				return nil, &UnmarshalError{Err: err}
+			}
+			return mergePatch(schema, patch, false), nil
+		})
+	}
+}
+
+// WithJSONPatch applies an RFC 6902 JSON Patch to the generated inputParam
+// schema, for e.g; [{"op": "add", "path": "/required/-", "value": "name"}].
+// A patch that can not be applied makes the generation fail with a
+// *PatchError.
+func WithJSONPatch(patchJSON []byte) GenerateOption {
+	return func(cfg *generatorConfig) {
+		cfg.overlays = append(cfg.overlays, func(schema interface{}) (interface{}, error) {
+			var ops []patchOperation
+			if err := json.Unmarshal(patchJSON, &ops); err != nil {
+				return nil, &UnmarshalError{Err: err}
+			}
+			return applyJSONPatch(schema, ops)
+		})
+	}
+}
+
+// WithProfile applies a profile to the non parameterized schema before the
+// definitions of the placeholders are looked up in it, for e.g; for the
+// generated inputParam schema to cap the vcpus as the "dev" profile does.
+// The profile is checked with CheckProfile; if it loosens a constraint
+// where it is forbidden to, the generation fails with a *ProfileError.
+func WithProfile(profile Profile) GenerateOption {
+	return func(cfg *generatorConfig) {
+		cfg.profile = &profile
+	}
+}
@@ -0,0 +1,106 @@
+package jsondatavalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Draft identifies the version of the JSON Schema specification a schema
+// is written against. The value of a Draft is its version number.
+type Draft int
+
+const (
+	// Draft4 represents http://json-schema.org/draft-04/schema
+	Draft4 Draft = 4
+	// Draft6 represents http://json-schema.org/draft-06/schema
+	Draft6 Draft = 6
+	// Draft7 represents http://json-schema.org/draft-07/schema
+	Draft7 Draft = 7
+	// Draft2019 represents https://json-schema.org/draft/2019-09/schema
+	Draft2019 Draft = 2019
+	// Draft2020 represents https://json-schema.org/draft/2020-12/schema
+	Draft2020 Draft = 2020
+
+	// DefaultDraft is the draft used for schemas without a `$schema` keyword
+	// when no draft is chosen with WithDraft
+	DefaultDraft = Draft7
+)
+
+// draftURLs maps the `$schema` urls known to this package to their draft
+var draftURLs = map[string]Draft{
+	"http://json-schema.org/draft-04/schema":       Draft4,
+	"http://json-schema.org/draft-06/schema":       Draft6,
+	"http://json-schema.org/draft-07/schema":       Draft7,
+	"https://json-schema.org/draft/2019-09/schema": Draft2019,
+	"https://json-schema.org/draft/2020-12/schema": Draft2020,
+}
+
+// String returns the name of the draft, for e.g; "draft7" or "draft2020-12"
+func (d Draft) String() string {
+	switch d {
+	case Draft4, Draft6, Draft7:
+		return fmt.Sprintf("draft%d", int(d))
+	case Draft2019:
+		return "draft2019-09"
+	case Draft2020:
+		return "draft2020-12"
+	}
+	return fmt.Sprintf("Draft(%d)", int(d))
+}
+
+// jsonschemaDraft returns the jsonschema library representation of the draft
+func (d Draft) jsonschemaDraft() (*jsonschema.Draft, error) {
+	switch d {
+	case Draft4:
+		return jsonschema.Draft4, nil
+	case Draft6:
+		return jsonschema.Draft6, nil
+	case Draft7:
+		return jsonschema.Draft7, nil
+	case Draft2019:
+		return jsonschema.Draft2019, nil
+	case Draft2020:
+		return jsonschema.Draft2020, nil
+	}
+	return nil, fmt.Errorf("unsupported json schema %s", d)
+}
+
+// DetectDraft returns the draft named by the `$schema` keyword of the
+// schema in schemaJSONBuf. The boolean is false if the schema has no
+// `$schema` keyword or names an unknown draft.
+func DetectDraft(schemaJSONBuf []byte) (Draft, bool) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(schemaJSONBuf, &schema); err != nil {
+		return 0, false
+	}
+	url, ok := schema["$schema"].(string)
+	if !ok {
+		return 0, false
+	}
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "#"), "/")
+	if strings.HasPrefix(url, "https://json-schema.org/draft-0") {
+		url = "http" + strings.TrimPrefix(url, "https")
+	}
+	d, ok := draftURLs[url]
+	return d, ok
+}
+
+// ValidatorOption configures a Validator created by NewValidator
+type ValidatorOption func(*validatorConfig)
+
+// validatorConfig holds the settings ValidatorOptions apply to
+type validatorConfig struct {
+	draft Draft
+}
+
+// WithDraft sets the draft used to compile the schema when it has no
+// `$schema` keyword. A schema with a `$schema` keyword is always compiled
+// with the draft it names.
+func WithDraft(d Draft) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.draft = d
+	}
+}
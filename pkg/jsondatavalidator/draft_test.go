@@ -0,0 +1,130 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestDetectDraft(t *testing.T) {
+	testTable := []struct {
+		description   string
+		schema        string
+		expectedDraft jsondatavalidator.Draft
+		expectedOk    bool
+	}{
+		{"draft-04", `{"$schema": "http://json-schema.org/draft-04/schema#"}`, jsondatavalidator.Draft4, true},
+		{"draft-06", `{"$schema": "http://json-schema.org/draft-06/schema#"}`, jsondatavalidator.Draft6, true},
+		{"draft-07", `{"$schema": "http://json-schema.org/draft-07/schema"}`, jsondatavalidator.Draft7, true},
+		{"2019-09", `{"$schema": "https://json-schema.org/draft/2019-09/schema"}`, jsondatavalidator.Draft2019, true},
+		{"2020-12", `{"$schema": "https://json-schema.org/draft/2020-12/schema"}`, jsondatavalidator.Draft2020, true},
+		{"No $schema", `{"type": "object"}`, 0, false},
+		{"Unknown $schema", `{"$schema": "http://example.com/schema"}`, 0, false},
+		{"Malformed JSON", `{"$schema":`, 0, false},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			d, ok := jsondatavalidator.DetectDraft([]byte(tdr.schema))
+			if d != tdr.expectedDraft || ok != tdr.expectedOk {
+				t.Errorf("expected %v %v, got %v %v", tdr.expectedDraft, tdr.expectedOk, d, ok)
+			}
+		})
+	}
+}
+
+func TestNewValidatorWithDraft(t *testing.T) {
+	// exclusiveMaximum is a boolean in draft4 and a number from draft6 onwards
+	draft4Schema := `{"type": "integer", "maximum": 16, "exclusiveMaximum": true}`
+
+	testTable := []struct {
+		description   string
+		schema        string
+		opts          []jsondatavalidator.ValidatorOption
+		expectedDraft jsondatavalidator.Draft
+		expectedErr   error
+	}{
+		{"Default draft", testVMSchema, nil, jsondatavalidator.DefaultDraft, nil},
+		{"Chosen draft", draft4Schema, []jsondatavalidator.ValidatorOption{jsondatavalidator.WithDraft(jsondatavalidator.Draft4)}, jsondatavalidator.Draft4, nil},
+		{"Wrong draft", draft4Schema, []jsondatavalidator.ValidatorOption{jsondatavalidator.WithDraft(jsondatavalidator.Draft7)}, 0, jsondatavalidator.ErrCompile},
+		{"$schema wins over chosen draft", `{"$schema": "http://json-schema.org/draft-04/schema#", "maximum": 16, "exclusiveMaximum": true}`,
+			[]jsondatavalidator.ValidatorOption{jsondatavalidator.WithDraft(jsondatavalidator.Draft2020)}, jsondatavalidator.Draft4, nil},
+		{"Unsupported draft", testVMSchema, []jsondatavalidator.ValidatorOption{jsondatavalidator.WithDraft(5)}, 0, jsondatavalidator.ErrCompile},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			v, err := jsondatavalidator.NewValidator(strings.NewReader(tdr.schema), "sch.json", tdr.opts...)
+			if tdr.expectedErr != nil {
+				if !errors.Is(err, tdr.expectedErr) {
+					t.Errorf("expected %v, got %v", tdr.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Draft() != tdr.expectedDraft {
+				t.Errorf("expected %v, got %v", tdr.expectedDraft, v.Draft())
+			}
+		})
+	}
+}
+
+func TestValidatorModernDraftKeywords(t *testing.T) {
+	testDeviceSchema := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$defs": {
+    "vcpuCount": {"type": "integer", "minimum": 2, "maximum": 16}
+  },
+  "type": "object",
+  "properties": {
+    "vcpus": {"$ref": "#/$defs/vcpuCount"},
+    "user": {"type": "string"},
+    "password": {"type": "string"},
+    "disks": {"type": "array", "prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false}
+  },
+  "dependentRequired": {"user": ["password"]},
+  "unevaluatedProperties": false
+}`
+
+	testTable := []struct {
+		description     string
+		jsonval         []byte
+		expectedKeyword string
+	}{
+		{"Valid", []byte(`{"vcpus": 4, "user": "root", "password": "x", "disks": ["sda", 10]}`), ""},
+		{"$defs", []byte(`{"vcpus": 32}`), "maximum"},
+		{"dependentRequired", []byte(`{"user": "root"}`), "dependentRequired"},
+		{"unevaluatedProperties", []byte(`{"vcpus": 4, "proc": 1}`), "unevaluatedProperties"},
+		{"prefixItems", []byte(`{"disks": [10, "sda"]}`), "type"},
+	}
+	v, err := jsondatavalidator.NewValidator(strings.NewReader(testDeviceSchema), "sch.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Draft() != jsondatavalidator.Draft2020 {
+		t.Errorf("expected draft to be detected from $schema, got %v", v.Draft())
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := v.ValidateWithResult(tdr.jsonval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(res.Violations)
+			if tdr.expectedKeyword == "" {
+				if !res.Valid() {
+					t.Error("expected no violations")
+				}
+				return
+			}
+			if res.Valid() || res.Violations[0].Keyword != tdr.expectedKeyword {
+				t.Errorf("expected a %q violation", tdr.expectedKeyword)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package jsondatavalidator
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidInput is matched, using errors.Is, by every error caused by
+	// the json buffer being validated, i.e; by errors that the caller of the
+	// library can fix by correcting the input
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrInvalidSchema is matched, using errors.Is, by every error caused by
+	// the schema the json buffer is validated against
+	ErrInvalidSchema = errors.New("invalid schema")
+
+	// ErrUnmarshal is matched by errors returned when the json buffer could not be decoded
+	ErrUnmarshal = errors.New("UnMarshallError")
+	// ErrAddResource is matched by errors returned when the schema could not be loaded
+	ErrAddResource = errors.New("AddResourceError")
+	// ErrCompile is matched by errors returned when the schema could not be compiled
+	ErrCompile = errors.New("CompilerError")
+	// ErrValidation is matched by errors returned when the json buffer does
+	// not validate against the schema
+	ErrValidation = errors.New("ValidationError")
+	// ErrMissingParameter is matched by errors returned when no value is
+	// given for a parameter of a parameterized template
+	ErrMissingParameter = errors.New("MissingParameterError")
+	// ErrDuplicateKey is matched by errors returned when a parameterized key
+	// renders to a key the object already holds, or a key of the object is
+	// the one a parameterized key rendered to
+	ErrDuplicateKey = errors.New("DuplicateKeyError")
+	// ErrUnresolvedParameter is matched by errors returned, in strict mode,
+	// when the definition of a parameter of a parameterized template is
+	// missing or ambiguous
+	ErrUnresolvedParameter = errors.New("UnresolvedParameterError")
+	// ErrParameterConflict is matched by errors returned, in strict mode,
+	// when the definitions of a parameter used in several places of a
+	// parameterized template can not be satisfied together, or its
+	// placeholders declare different types or default values
+	ErrParameterConflict = errors.New("ParameterConflictError")
+	// ErrDefinitionMismatch is matched by errors returned, in strict mode,
+	// when the type or default value a placeholder declares, or the string
+	// it is embedded in, does not fit the definition of its parameter
+	ErrDefinitionMismatch = errors.New("DefinitionMismatchError")
+	// ErrPatch is matched by errors returned when an operation of a JSON
+	// Patch can not be applied
+	ErrPatch = errors.New("PatchError")
+	// ErrUnknownProfile is matched by errors returned when no profile of a
+	// ProfileSet has the name asked for
+	ErrUnknownProfile = errors.New("UnknownProfileError")
+	// ErrLoosenedProfile is matched by errors returned when a profile
+	// loosens, or may loosen, a constraint where it is forbidden to
+	ErrLoosenedProfile = errors.New("LoosenedProfileError")
+)
+
+// UnmarshalError is returned when the json buffer could not be decoded.
+// It matches ErrUnmarshal and ErrInvalidInput.
+type UnmarshalError struct {
+	// Err is the error returned by the yaml decoder
+	Err error
+}
+
+func (e *UnmarshalError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrUnmarshal, e.Err)
+}
+
+// Unwrap returns the error returned by the yaml decoder
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrUnmarshal or ErrInvalidInput
+func (e *UnmarshalError) Is(target error) bool {
+	return target == ErrUnmarshal || target == ErrInvalidInput
+}
+
+// AddResourceError is returned when the schema could not be loaded.
+// It matches ErrAddResource and ErrInvalidSchema.
+type AddResourceError struct {
+	// URL is the url the schema was to be loaded as
+	URL string
+	// Err is the error returned by the jsonschema loader
+	Err error
+}
+
+func (e *AddResourceError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", ErrAddResource, e.URL, e.Err)
+}
+
+// Unwrap returns the error returned by the jsonschema loader
+func (e *AddResourceError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrAddResource or ErrInvalidSchema
+func (e *AddResourceError) Is(target error) bool {
+	return target == ErrAddResource || target == ErrInvalidSchema
+}
+
+// CompileError is returned when the schema could not be compiled.
+// It matches ErrCompile and ErrInvalidSchema.
+type CompileError struct {
+	// URL is the url of the schema that failed to compile
+	URL string
+	// Err is the error returned by the jsonschema compiler
+	Err error
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", ErrCompile, e.URL, e.Err)
+}
+
+// Unwrap returns the error returned by the jsonschema compiler
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrCompile or ErrInvalidSchema
+func (e *CompileError) Is(target error) bool {
+	return target == ErrCompile || target == ErrInvalidSchema
+}
+
+// ValidationError is returned when the json buffer does not validate
+// against the schema. Its message is that of the last violation; all of
+// them are available in Result. It matches ErrValidation and ErrInvalidInput.
+type ValidationError struct {
+	Result *ValidationResult
+}
+
+func (e *ValidationError) Error() string {
+	if e.Result == nil || e.Result.Valid() {
+		return ErrValidation.Error()
+	}
+	return e.Result.Violations[len(e.Result.Violations)-1].String()
+}
+
+// Is reports whether the target is ErrValidation or ErrInvalidInput
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation || target == ErrInvalidInput
+}
+
+// ParameterError is returned when a parameter of a parameterized template
+// can not be substituted. It matches Err and ErrInvalidInput.
+type ParameterError struct {
+	// Name is the name of the parameter
+	Name string
+	// Pointer is the json-pointer of the placeholder in the template
+	Pointer string
+	// Err describes why the parameter can not be substituted, for e.g;
+	// ErrMissingParameter
+	Err error
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("%v: parameter %q at %s", e.Err, e.Name, e.Pointer)
+}
+
+// Unwrap returns the reason the parameter can not be substituted
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrInvalidInput
+func (e *ParameterError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// DiagnosticError is returned, in strict mode, when the inputParam schema of
+// a parameterized template would accept values the non parameterized schema
+// does not define. Its message is that of the first diagnostic; all of them
+// are available in Diagnostics. It matches ErrInvalidSchema and the error
+// of the code of each of its diagnostics: ErrUnresolvedParameter,
+// ErrParameterConflict or ErrDefinitionMismatch.
+type DiagnosticError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *DiagnosticError) Error() string {
+	switch len(e.Diagnostics) {
+	case 0:
+		return ErrInvalidSchema.Error()
+	case 1:
+		return fmt.Sprintf("%s: %s", e.Diagnostics[0].Code.err(), e.Diagnostics[0])
+	}
+	return fmt.Sprintf("%s: %s (and %d more)", e.Diagnostics[0].Code.err(), e.Diagnostics[0], len(e.Diagnostics)-1)
+}
+
+// Is reports whether the target is ErrInvalidSchema or the error of the
+// code of one of the diagnostics
+func (e *DiagnosticError) Is(target error) bool {
+	if target == ErrInvalidSchema {
+		return true
+	}
+	for _, d := range e.Diagnostics {
+		if target == d.Code.err() {
+			return true
+		}
+	}
+	return false
+}
+
+// PatchError is returned when an operation of a JSON Patch can not be
+// applied. It matches ErrPatch and ErrInvalidInput.
+type PatchError struct {
+	// Index is the index of the operation in the patch
+	Index int
+	// Op and Path are those of the operation, for e.g; "replace" and "/vm/vcpus"
+	Op   string
+	Path string
+	// Err describes why the operation can not be applied
+	Err error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("%s: operation %d (%s %q): %v", ErrPatch, e.Index, e.Op, e.Path, e.Err)
+}
+
+// Unwrap returns the reason the operation can not be applied
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrPatch or ErrInvalidInput
+func (e *PatchError) Is(target error) bool {
+	return target == ErrPatch || target == ErrInvalidInput
+}
+
+// ProfileError is returned when a profile loosens, or may loosen,
+// constraints of its base schema where it is forbidden to. Its message is that of the first
+// change; all of them are available in Changes. It matches
+// ErrLoosenedProfile and ErrInvalidSchema.
+type ProfileError struct {
+	// Profile is the name of the profile
+	Profile string
+	// Changes are the changes that loosen, or may loosen, the constraints
+	Changes []SchemaChange
+}
+
+func (e *ProfileError) Error() string {
+	switch len(e.Changes) {
+	case 0:
+		return fmt.Sprintf("%s: profile %q", ErrLoosenedProfile, e.Profile)
+	case 1:
+		return fmt.Sprintf("%s: profile %q: %s", ErrLoosenedProfile, e.Profile, e.Changes[0])
+	}
+	return fmt.Sprintf("%s: profile %q: %s (and %d more)", ErrLoosenedProfile, e.Profile, e.Changes[0], len(e.Changes)-1)
+}
+
+// Is reports whether the target is ErrLoosenedProfile or ErrInvalidSchema
+func (e *ProfileError) Is(target error) bool {
+	return target == ErrLoosenedProfile || target == ErrInvalidSchema
+}
@@ -0,0 +1,123 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestValidateJSONBufAgainstSchemaErrors(t *testing.T) {
+	testTable := []struct {
+		description   string
+		jsonval       []byte
+		schema        string
+		url           string
+		expectedIs    []error
+		expectedIsNot []error
+	}{
+		{"Malformed JSON", []byte(`{"key":`), testVMSchema, "sch.json",
+			[]error{jsondatavalidator.ErrUnmarshal, jsondatavalidator.ErrInvalidInput},
+			[]error{jsondatavalidator.ErrInvalidSchema}},
+		{"Invalid URL", []byte(`{"key": "val"}`), "dummy", "d",
+			[]error{jsondatavalidator.ErrAddResource, jsondatavalidator.ErrInvalidSchema},
+			[]error{jsondatavalidator.ErrInvalidInput}},
+		{"Invalid schema", []byte(`{"key": "val"}`), `{"type": 1}`, "sch.json",
+			[]error{jsondatavalidator.ErrCompile, jsondatavalidator.ErrInvalidSchema},
+			[]error{jsondatavalidator.ErrInvalidInput, jsondatavalidator.ErrAddResource}},
+		{"Invalid JSON data", []byte(`{"vm": {}}`), testVMSchema, "sch.json",
+			[]error{jsondatavalidator.ErrValidation, jsondatavalidator.ErrInvalidInput},
+			[]error{jsondatavalidator.ErrInvalidSchema}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			err := jsondatavalidator.ValidateJSONBufAgainstSchema(tdr.jsonval, strings.NewReader(tdr.schema), tdr.url)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			t.Log(err)
+			for _, target := range tdr.expectedIs {
+				if !errors.Is(err, target) {
+					t.Errorf("expected errors.Is(err, %q)", target)
+				}
+			}
+			for _, target := range tdr.expectedIsNot {
+				if errors.Is(err, target) {
+					t.Errorf("did not expect errors.Is(err, %q)", target)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorsAs(t *testing.T) {
+	err := jsondatavalidator.ValidateJSONBufAgainstSchema([]byte(`{"key":`), strings.NewReader(testVMSchema), "sch.json")
+	var uerr *jsondatavalidator.UnmarshalError
+	if !errors.As(err, &uerr) || uerr.Err == nil {
+		t.Errorf("expected *UnmarshalError wrapping the yaml error, got %v", err)
+	}
+
+	err = jsondatavalidator.ValidateJSONBufAgainstSchema([]byte(`{}`), strings.NewReader(`{"type": 1}`), "sch.json")
+	var cerr *jsondatavalidator.CompileError
+	if !errors.As(err, &cerr) || cerr.URL != "sch.json" {
+		t.Errorf("expected *CompileError for sch.json, got %v", err)
+	}
+	var serr *jsonschema.SchemaError
+	if !errors.As(err, &serr) {
+		t.Errorf("expected the compiler's *jsonschema.SchemaError to be kept, got %v", err)
+	}
+
+	err = jsondatavalidator.ValidateJSONBufAgainstSchema([]byte(`{"vm": {}}`), strings.NewReader(testVMSchema), "sch.json")
+	var verr *jsondatavalidator.ValidationError
+	if !errors.As(err, &verr) || verr.Result.Valid() {
+		t.Errorf("expected *ValidationError with violations, got %v", err)
+	}
+}
+
+func TestDiagnosticErrorIs(t *testing.T) {
+	testTable := []struct {
+		description   string
+		template      []byte
+		expectedIs    []error
+		expectedIsNot []error
+	}{
+		{"Unresolved parameter", []byte("vm:\n  cpu: ${cpu}\n"),
+			[]error{jsondatavalidator.ErrUnresolvedParameter, jsondatavalidator.ErrInvalidSchema},
+			[]error{jsondatavalidator.ErrParameterConflict, jsondatavalidator.ErrDefinitionMismatch, jsondatavalidator.ErrInvalidInput}},
+		{"Conflicting definitions", []byte("vm:\n  vcpus: ${count}\n  name: ${count}\n"),
+			[]error{jsondatavalidator.ErrParameterConflict, jsondatavalidator.ErrInvalidSchema},
+			[]error{jsondatavalidator.ErrUnresolvedParameter, jsondatavalidator.ErrDefinitionMismatch, jsondatavalidator.ErrInvalidInput}},
+		{"Declared type not allowed", []byte("vm:\n  vcpus: ${vcpus:string}\n"),
+			[]error{jsondatavalidator.ErrDefinitionMismatch, jsondatavalidator.ErrInvalidSchema},
+			[]error{jsondatavalidator.ErrUnresolvedParameter, jsondatavalidator.ErrParameterConflict, jsondatavalidator.ErrInvalidInput}},
+		{"Several diagnostics", []byte("vm:\n  vcpus: ${vcpus:string}\n  cpu: ${cpu}\n"),
+			[]error{jsondatavalidator.ErrDefinitionMismatch, jsondatavalidator.ErrUnresolvedParameter},
+			[]error{jsondatavalidator.ErrParameterConflict}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			_, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(tdr.template, testJSONNonParamSchema,
+				testInputParamJSONSchema, nil, `\$\{([^}]*)\}`, jsondatavalidator.WithStrict())
+			var derr *jsondatavalidator.DiagnosticError
+			if !errors.As(err, &derr) {
+				t.Fatalf("expected a *DiagnosticError, got %v", err)
+			}
+			t.Log(err)
+			for _, target := range tdr.expectedIs {
+				if !errors.Is(err, target) {
+					t.Errorf("expected errors.Is(err, %q)", target)
+				}
+			}
+			for _, target := range tdr.expectedIsNot {
+				if errors.Is(err, target) {
+					t.Errorf("did not expect errors.Is(err, %q)", target)
+				}
+			}
+		})
+	}
+}
@@ -2,19 +2,14 @@ package jsondatavalidator
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
 	"io"
 	"reflect"
+	"sort"
 
-	"github.com/peterbourgon/mergemap"
 	log "github.com/sirupsen/logrus"
 
 	"regexp"
 	"strings"
-
-	"github.com/ghodss/yaml"
-	"github.com/santhosh-tekuri/jsonschema"
 )
 
 func init() {
@@ -32,6 +27,8 @@ const (
 	KeyRequired = "required"
 	// KeyProperties holds name of a key in a map
 	KeyProperties = "properties"
+	// KeyDefinitions holds name of a key in a map
+	KeyDefinitions = "definitions"
 )
 
 // SearchResults stores the results when parsing a map structure for
@@ -79,34 +76,44 @@ func (resmap *SearchResults) UpdateSearchResults(val interface{}) {
 // iii) a string (one of `schema.json` or `sch.json`) that represents if
 // schema definition is in a file or in memory
 // The function returns an error if the json buffer does not validate against
-// the defined schema
+// the defined schema. The errors returned match the sentinel errors of this
+// package using errors.Is. When validation fails, the message of the
+// returned *ValidationError is that of the last violation, use
+// ValidateJSONBufAgainstSchemaWithResult to obtain all of them.
 func ValidateJSONBufAgainstSchema(jsonval []byte,
 	schemaDefAsReaderObj io.Reader, url string) error {
 	log.Debug()
-	var m interface{}
-	err := yaml.Unmarshal(jsonval, &m)
+	res, err := ValidateJSONBufAgainstSchemaWithResult(jsonval, schemaDefAsReaderObj, url)
 	if err != nil {
-		log.WithFields(log.Fields{"UnMarshallError": err}).Error()
-		return fmt.Errorf("UnMarshallError")
+		return err
 	}
-	compiler := jsonschema.NewCompiler()
-	//compiler.Draft = jsonschema.Draft4
-	if err := compiler.AddResource(url, schemaDefAsReaderObj); err != nil {
-		log.WithFields(log.Fields{"AddResourceError": err}).Error()
-		return fmt.Errorf("AddResourceError")
+	if !res.Valid() {
+		return &ValidationError{Result: res}
 	}
-	schema, err := compiler.Compile(url)
+	return nil
+}
+
+// ValidateJSONBufAgainstSchemaWithResult takes the same arguments as
+// ValidateJSONBufAgainstSchema. It returns an error only if the json buffer
+// or the schema could not be processed; violations of the schema are
+// returned, all of them, in the ValidationResult.
+func ValidateJSONBufAgainstSchemaWithResult(jsonval []byte,
+	schemaDefAsReaderObj io.Reader, url string) (*ValidationResult, error) {
+	log.Debug()
+	m, err := decodeJSONBuf(jsonval)
 	if err != nil {
-		log.WithFields(log.Fields{"CompileError": err}).Error()
-		return fmt.Errorf("CompilerError")
+		return nil, err
 	}
-
-	if zerr := schema.ValidateInterface(m); zerr != nil {
-		l := len(strings.Split(zerr.Error(), "\n"))
-		log.WithFields(log.Fields{"SchemaValidateInterfaceError": zerr}).Error()
-		return errors.New(strings.Split(zerr.Error(), "\n")[l-1])
+	v, err := NewValidator(schemaDefAsReaderObj, url)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	res, err := v.validateInterface(m)
+	if err != nil {
+		return nil, err
+	}
+	res.locate(jsonval)
+	return res, nil
 }
 
 // GetRegexMatchingListFromJSONBuff returns a list of strings that match
@@ -215,25 +222,74 @@ func (resmap *SearchResults) ParseArray(anArray []interface{}) {
 // allowable values for those parameterized variables.
 func GenerateJSONSchemaFromParameterizedTemplate(parameterizedJSON []byte,
 	nonParamDefineJSONBuf []byte, inputParamSchemaJSONBuf []byte,
-	keysToAddToRequiredSection []string, regExpStr string) ([]byte, error) {
+	keysToAddToRequiredSection []string, regExpStr string, opts ...GenerateOption) ([]byte, error) {
 
 	log.Debug()
+	res, err := GenerateJSONSchemaFromParameterizedTemplateWithResult(parameterizedJSON,
+		nonParamDefineJSONBuf, inputParamSchemaJSONBuf, keysToAddToRequiredSection, regExpStr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return res.Schema, nil
+}
 
-	// The regexp looks for the ```regExpStr``` anywhere in the line and returns the entire line
-	slist := GetRegexMatchingListFromJSONBuff(parameterizedJSON, `.*`+regExpStr+`.*`)
-	log.WithFields(log.Fields{"RegexMatchingList": slist}).Debug()
+// GenerateResult is the outcome of GenerateJSONSchemaFromParameterizedTemplateWithResult
+type GenerateResult struct {
+	// Schema is the generated inputParam schema
+	Schema json.RawMessage `json:"schema"`
+	// Placeholders are the placeholders found in the template
+	Placeholders []Placeholder `json:"placeholders"`
+	// Diagnostics are the problems found while looking up the definition
+	// of each placeholder
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// GenerateJSONSchemaFromParameterizedTemplateWithResult takes the same
+// arguments as GenerateJSONSchemaFromParameterizedTemplate. The definition
+// of each placeholder is looked up by its location in the template, for
+// e.g; "#/vm/name" is defined by the "name" property of the "vm" schema and
+// not by any other "name" property of the non parameterized schema, and
+// "$ref" and "allOf" are followed on the way. The branches of a definition
+// that accept the placeholder itself, rather than a value, are removed. The
+// placeholders whose definition is missing or ambiguous are reported as
+// diagnostics, and accept any value; unless the WithStrict option is set,
+// in which case a *DiagnosticError is returned. The generated schema is
+// the same for the same arguments: its keys and its "required" list are
+// sorted.
+func GenerateJSONSchemaFromParameterizedTemplateWithResult(parameterizedJSON []byte,
+	nonParamDefineJSONBuf []byte, inputParamSchemaJSONBuf []byte,
+	keysToAddToRequiredSection []string, regExpStr string, opts ...GenerateOption) (*GenerateResult, error) {
 
-	mapParameterizedParamAndDefinition := CreateRevMapStructFromGivenStringListWithSpecifiedSeparator(slist, ":", "-")
-	log.WithFields(log.Fields{"mapParameterizedParamAndDefinition": mapParameterizedParamAndDefinition}).Debug()
+	log.Debug()
+	cfg := generatorConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 
-	rxp, err := regexp.Compile(regExpStr)
+	syntax := cfg.syntax
+	if syntax == nil {
+		var err error
+		if syntax, err = NewRegexpSyntax(regExpStr); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.profile != nil {
+		var err error
+		if nonParamDefineJSONBuf, _, err = CheckProfile(nonParamDefineJSONBuf, *cfg.profile); err != nil {
+			return nil, err
+		}
+	}
+	placeholders, err := discoverPlaceholders(parameterizedJSON, syntax)
 	if err != nil {
 		return nil, err
 	}
-	propjson := createSchemaForInputParamsFromParameterizedProperties(
-		mapParameterizedParamAndDefinition,
-		nonParamDefineJSONBuf,
-		rxp)
+	propjson, diags := createSchemaForInputParamsFromParameterizedProperties(
+		placeholders,
+		nonParamDefineJSONBuf, syntax)
+	if cfg.strict && len(diags) > 0 {
+		log.WithFields(log.Fields{"Diagnostics": diags}).Error()
+		return nil, &DiagnosticError{Diagnostics: diags}
+	}
 
 	var src map[string]interface{}
 	_ = json.Unmarshal(propjson, &src)
@@ -241,49 +297,65 @@ func GenerateJSONSchemaFromParameterizedTemplate(parameterizedJSON []byte,
 	var inputParamSchemaMap map[string]interface{}
 	_ = json.Unmarshal(inputParamSchemaJSONBuf, &inputParamSchemaMap)
 
-	inter := mergemap.Merge(inputParamSchemaMap, src)
+	// the generated definitions are merged as with MergePatch, except that
+	// their null values, for e.g; a null default, are kept
+	inter := mergePatch(inputParamSchemaMap, src, true)
 
 	reqjson := createSchemaForInputParamsWithRequiredSection(len(src),
-		mapParameterizedParamAndDefinition, keysToAddToRequiredSection, rxp)
+		placeholders, keysToAddToRequiredSection)
 	var req map[string]interface{}
 	_ = json.Unmarshal(reqjson, &req)
 
-	final := mergemap.Merge(inter, req)
+	final, _ := mergePatch(inter, req, true).(map[string]interface{})
 
-	r, e := json.Marshal(final["inputParam"])
+	schema := final[KeyInputParam]
+	for _, overlay := range cfg.overlays {
+		var err error
+		if schema, err = overlay(schema); err != nil {
+			return nil, err
+		}
+	}
+	r, e := json.Marshal(schema)
+	if e == nil && cfg.canonical {
+		r, e = CanonicalizeJSON(r)
+	}
 	log.Debug(string(r), e)
-	return r, e
+	if e != nil {
+		return nil, e
+	}
+	return &GenerateResult{Schema: r, Placeholders: placeholders, Diagnostics: diags}, nil
 }
 
 // createSchemaForInputParamsWithRequiredSection takes as argument:
 // i) reqCnt : number of keys to be added to the "required" section of the inputParams
-// ii) a map that contains as its
-//		key: the parameterized param from the parameterized template
-//		value: the definition key that can be looked up in the json schema for allowable format and values
+// ii) the placeholders found in the parameterized template
 // iii) keysToAddToRequiredSection: pre-defined keys to be added to 'required' section of json schema
-// iv) rxp: compiled regexp that is used for pattern match and deriving value for key
 func createSchemaForInputParamsWithRequiredSection(reqCnt int,
-	m map[string]interface{}, keysToAddToRequiredSection []string,
-	rxp *regexp.Regexp) []byte {
+	placeholders []Placeholder, keysToAddToRequiredSection []string) []byte {
 	log.Debug()
 	reqmap := make(map[string]map[string]interface{})
 	reqmap[KeyInputParam] = make(map[string]interface{})
 	reqmap[KeyInputParam][KeyRequired] = make([]string, reqCnt)
 
-	keys := make([]string, len(m))
-	i := 0
-	for k := range m {
-		res := rxp.FindStringSubmatch(k)
-		// FindStringSubmatch will return for e.g; "[>>memory memory]",
-		// we want to pick the last element in the array
-		l := len(res)
-		key := res[l-1]
-		log.WithFields(log.Fields{"res": res, "len": l, "key": key}).Debug()
-		keys[i] = key
-		i++
+	keys := make([]string, 0, len(placeholders)+len(keysToAddToRequiredSection))
+	seen := make(map[string]bool)
+	for _, ph := range placeholders {
+		// a parameter may be used more than once in the template, and a
+		// parameter that is optional or has a default value need not be given
+		if !seen[ph.Name] && isRequired(ph.Name, placeholders) {
+			seen[ph.Name] = true
+			keys = append(keys, ph.Name)
+		}
 	}
-
-	keys = append(keys, keysToAddToRequiredSection...)
+	// the items of "required" must be unique for the schema to compile
+	for _, k := range keysToAddToRequiredSection {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	// sorted, for the generated schema not to depend on the order of the template
+	sort.Strings(keys)
 	reqmap[KeyInputParam][KeyRequired] = keys
 
 	reqjson, e := json.Marshal(reqmap)
@@ -295,14 +367,29 @@ func createSchemaForInputParamsWithRequiredSection(reqCnt int,
 }
 
 // createSchemaForInputParamsFromParameterizedProperties takes as argument:
-// i) a map that contains as its
-// 		key: the parameterized param from the parameterized template
-//		value: the definition key that can be looked up in the json schema for allowable format and values
+// i) the placeholders found in the parameterized template, the location of
+// each placeholder is looked up in the json schema for allowable format
+// and values
 // ii) schemaJSON: json schema that contains property definitions and formats
-// iii) rxp: compiled regexp that is used for pattern match and deriving value for key
-// The function returns dynamically created Schema for the "input_param" as JSON buffer
-func createSchemaForInputParamsFromParameterizedProperties(m map[string]interface{}, schemaJSON []byte,
-	rxp *regexp.Regexp) []byte {
+// iii) syntax: the syntax of the placeholders of the template
+// A placeholder embedded in a longer string is defined by the constraints
+// its part of the string can be checked against on its own. A placeholder
+// in a key is defined by the names the object that holds it allows, and a
+// placeholder in an array item by the "items" of the array.
+// The function returns dynamically created Schema for the "input_param" as
+// JSON buffer, holding the "definitions" referred to by the definitions of
+// the placeholders, and a diagnostic for each placeholder whose definition is
+// missing or ambiguous. Such a placeholder is defined by the empty schema,
+// that accepts any value, for the generated schema to stay satisfiable.
+// A parameter used in several places is defined by the definitions of
+// every use, combined with "allOf", and a diagnostic is returned for the
+// constraints they can not satisfy together. The type and the default
+// value a placeholder declares, for e.g; "${memory:integer=1024}", are
+// checked against its definition and added to it if it allows them; else
+// a diagnostic is returned, and a parameter whose default value is not
+// valid is required.
+func createSchemaForInputParamsFromParameterizedProperties(placeholders []Placeholder,
+	schemaJSON []byte, syntax PlaceholderSyntax) ([]byte, []Diagnostic) {
 	log.Debug()
 	var schema map[string]interface{}
 	//_ = yaml.Unmarshal(schemaJSON, &schema)
@@ -312,24 +399,71 @@ func createSchemaForInputParamsFromParameterizedProperties(m map[string]interfac
 	propmap[KeyInputParam] = make(map[string]map[string]interface{})
 	propmap[KeyInputParam][KeyProperties] = make(map[string]interface{})
 
-	for k, v := range m {
-		pvm := NewSearchResults(MatchKey, v.(string))
-		pvm.ParseMap(schema)
-		for _, elem := range pvm.Results {
-			switch elem.(type) {
-			case map[string]interface{}:
-				res := rxp.FindStringSubmatch(k)
-				// FindStringSubmatch will return for e.g; "[>>memory memory]",
-				// we want to pick the last element in the array
-				l := len(res)
-				key := res[l-1]
-				log.WithFields(log.Fields{"res": res, "len": l, "key": key}).Debug()
-				propmap[KeyInputParam][KeyProperties][key] = elem
+	resolver := newDefinitionResolver(schema, syntax)
+	props := propmap[KeyInputParam][KeyProperties]
+	uses := make(map[string][]parameterUse)
+	var names []string
+	var diags []Diagnostic
+	for _, ph := range placeholders {
+		if _, ok := uses[ph.Name]; !ok {
+			names = append(names, ph.Name)
+			uses[ph.Name] = nil
+		}
+		ptr := ph.Pointer
+		if ph.Kind == PlaceholderKey {
+			// a key is defined by the object that holds it
+			ptr = parentPtr(ptr)
+		}
+		locs := resolver.resolve(ptr)
+		switch len(locs) {
+		case 0:
+			diags = append(diags, Diagnostic{Code: DiagnosticUnresolved, Parameter: ph.Name,
+				Pointer: ph.Pointer, Message: "no definition found in the non parameterized schema"})
+		case 1:
+			log.WithFields(log.Fields{"placeholder": ph.Raw, "key": ph.Name, "definition": locs[0].ptr}).Debug()
+			var def map[string]interface{}
+			if ph.Kind == PlaceholderKey {
+				def = resolver.keyDefinition(locs[0])
+			} else {
+				def = resolver.definition(locs[0])
+				stripPlaceholderBranches(def, ph.Raw)
+			}
+			if ph.Embedded {
+				if !acceptsString(def) {
+					diags = append(diags, Diagnostic{Code: DiagnosticNotAString, Parameter: ph.Name,
+						Pointer: ph.Pointer, Message: "placeholder embedded in a value that is not a string"})
+				}
+				var embDiags []Diagnostic
+				def, embDiags = embeddedDefinition(def, ph, syntax)
+				diags = append(diags, embDiags...)
 			}
+			diags = append(diags, checkDeclarations(ph, def)...)
+			uses[ph.Name] = append(uses[ph.Name], parameterUse{ph: ph, ptr: locs[0].ptr, def: def})
+		default:
+			candidates := make([]string, len(locs))
+			for i, loc := range locs {
+				candidates[i] = loc.ptr
+			}
+			diags = append(diags, Diagnostic{Code: DiagnosticAmbiguous, Parameter: ph.Name,
+				Pointer: ph.Pointer, Candidates: candidates,
+				Message: "more than one definition found in the non parameterized schema"})
 		}
 	}
+	for _, name := range names {
+		// a parameter with no definition accepts any value, for the
+		// generated schema to stay satisfiable
+		props[name] = combineDefinitions(uses[name])
+		diags = append(diags, findConflicts(name, uses[name])...)
+		diags = append(diags, applyDeclarations(props[name].(map[string]interface{}), resolver.defs, name, placeholders)...)
+	}
+	if len(resolver.defs) > 0 {
+		propmap[KeyInputParam][KeyDefinitions] = resolver.defs
+	}
+	for _, d := range diags {
+		log.WithFields(log.Fields{"Diagnostic": d.String()}).Debug()
+	}
 
 	propjson, _ := json.Marshal(propmap)
 
-	return propjson
+	return propjson, diags
 }
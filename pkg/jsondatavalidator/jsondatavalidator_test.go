@@ -5,6 +5,7 @@
 package jsondatavalidator_test
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
@@ -250,11 +251,11 @@ func TestValidateJSONBufAgainstSchema(t *testing.T) {
 		url                  string
 		expectedOutput       error
 	}{
-		{"Invalid URL", []byte(`{"key": "val"}`), strings.NewReader("dummy"), "d", fmt.Errorf("AddResourceError")},
-		{"Malformed JSON", []byte(`{"key":`), strings.NewReader("dummy"), "d", fmt.Errorf("UnMarshallError")},
+		{"Invalid URL", []byte(`{"key": "val"}`), strings.NewReader("dummy"), "d", jsondatavalidator.ErrAddResource},
+		{"Malformed JSON", []byte(`{"key":`), strings.NewReader("dummy"), "d", jsondatavalidator.ErrUnmarshal},
 		{"Valid JSON", testValidJSONData, strings.NewReader(string(testValidSchema)), "sch.json", nil},
-		{"Invalid: additional property", testInvalidAdditionalProperty, strings.NewReader(string(testValidSchema)), "sch.json", fmt.Errorf("I[#/vm] S[#/properties/vm/additionalProperties] additionalProperties \"proc\" not allowed")},
-		{"Invalid: missing required property", testInValidJSONData, strings.NewReader(string(testValidSchema)), "sch.json", fmt.Errorf("I[#/vm] S[#/properties/vm/required] missing properties: \"vcpus\"")},
+		{"Invalid: additional property", testInvalidAdditionalProperty, strings.NewReader(string(testValidSchema)), "sch.json", fmt.Errorf("I[#/vm] S[#/properties/vm/additionalProperties] additionalProperties 'proc' not allowed")},
+		{"Invalid: missing required property", testInValidJSONData, strings.NewReader(string(testValidSchema)), "sch.json", fmt.Errorf("I[#/vm] S[#/properties/vm/required] missing properties: 'vcpus'")},
 	}
 	for i, tdr := range testTable {
 		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
@@ -264,7 +265,7 @@ func TestValidateJSONBufAgainstSchema(t *testing.T) {
 				t.Log(err.Error())
 			}
 
-			if err == tdr.expectedOutput {
+			if errors.Is(err, tdr.expectedOutput) {
 
 			} else if strings.TrimSpace(err.Error()) != tdr.expectedOutput.Error() {
 				t.Log(len(err.Error()))
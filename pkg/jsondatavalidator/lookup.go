@@ -0,0 +1,680 @@
+package jsondatavalidator
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	resyntax "regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// schemaKeywords are the keywords that make a map a schema rather than a
+// container of schemas, such as the "vmDeviceDefine" map of a non
+// parameterized schema. Keywords that do not constrain the value, such as
+// "definitions", are left out so that the root of a non parameterized
+// schema can hold the definitions its containers refer to.
+var schemaKeywords = []string{
+	"$ref", "type", "enum", "const", "format",
+	"properties", "patternProperties", "additionalProperties", "required",
+	"propertyNames", "minProperties", "maxProperties", "dependencies",
+	"dependentRequired", "dependentSchemas", "unevaluatedProperties",
+	"items", "prefixItems", "additionalItems", "contains", "minItems",
+	"maxItems", "uniqueItems", "unevaluatedItems",
+	"allOf", "anyOf", "oneOf", "not", "if", "then", "else",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "pattern",
+}
+
+// definitionsKeywords are the keywords that hold the definitions a schema refers to
+var definitionsKeywords = []string{"definitions", "$defs"}
+
+// schemaLocation is a sub schema of a schema document and its json-pointer
+type schemaLocation struct {
+	ptr    string
+	schema map[string]interface{}
+}
+
+// isSchema returns true if the map holds at least one schema keyword
+func isSchema(m map[string]interface{}) bool {
+	for _, kw := range schemaKeywords {
+		if _, ok := m[kw]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPtr splits a json-pointer, such as "#/vm/vcpus", into its unescaped tokens
+func splitPtr(ptr string) []string {
+	ptr = strings.TrimPrefix(strings.TrimPrefix(ptr, "#"), "/")
+	if ptr == "" {
+		return nil
+	}
+	tokens := strings.Split(ptr, "/")
+	for i, tok := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens
+}
+
+// parentPtr returns the json-pointer of the parent of the value at ptr
+func parentPtr(ptr string) string {
+	if i := strings.LastIndex(ptr, "/"); i >= 0 {
+		return ptr[:i]
+	}
+	return ptr
+}
+
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// definitionResolver looks up the definitions of the values of a template
+// in a non parameterized schema, following the "$ref" and "allOf" of the
+// schemas it meets
+type definitionResolver struct {
+	doc map[string]interface{}
+	// syntax finds the placeholders of the template, a token of a template
+	// json-pointer that holds one is a parameterized key
+	syntax PlaceholderSyntax
+	// defs holds the schemas referred to by the definitions returned by
+	// definition, keyed by their name in the generated schema
+	defs map[string]interface{}
+	// names maps the "$ref" of a schema in defs to its name
+	names map[string]string
+}
+
+// newDefinitionResolver returns a definitionResolver for the schema document
+// and the syntax of the placeholders of the template
+func newDefinitionResolver(doc map[string]interface{}, syntax PlaceholderSyntax) *definitionResolver {
+	return &definitionResolver{
+		doc:    doc,
+		syntax: syntax,
+		defs:   make(map[string]interface{}),
+		names:  make(map[string]string),
+	}
+}
+
+// resolve returns the sub schemas of the schema document that define the
+// value at the json-pointer of a template. The pointer is followed from
+// the root of the document, through the "properties" and "items" of the
+// schemas it meets. If the root does not define the value, the pointer is
+// followed from each container of schemas instead, for e.g; from the
+// "vmDeviceDefine" map of a non parameterized schema. More than one
+// location is returned when the pointer can be followed from more than
+// one container.
+func (r *definitionResolver) resolve(ptr string) []schemaLocation {
+	tokens := splitPtr(ptr)
+	if loc, ok := r.follow(schemaLocation{ptr: "#", schema: r.doc}, tokens); ok {
+		return []schemaLocation{loc}
+	}
+	var found []schemaLocation
+	for _, anchor := range containers(schemaLocation{ptr: "#", schema: r.doc}) {
+		if anchor.ptr == "#" {
+			continue
+		}
+		if loc, ok := r.follow(anchor, tokens); ok {
+			found = append(found, loc)
+		}
+	}
+	return found
+}
+
+// containers returns the location and every descendant of it that is a
+// container of schemas, i.e; a map reached through maps that are not schemas
+func containers(loc schemaLocation) []schemaLocation {
+	if isSchema(loc.schema) {
+		return nil
+	}
+	found := []schemaLocation{loc}
+	for _, k := range sortedKeys(loc.schema) {
+		if isDefinitionsKeyword(k) {
+			continue
+		}
+		if m, ok := loc.schema[k].(map[string]interface{}); ok {
+			found = append(found, containers(schemaLocation{ptr: loc.ptr + "/" + escapePtrToken(k), schema: m})...)
+		}
+	}
+	return found
+}
+
+// isDefinitionsKeyword returns true if the key holds the definitions a schema refers to
+func isDefinitionsKeyword(k string) bool {
+	for _, kw := range definitionsKeywords {
+		if k == kw {
+			return true
+		}
+	}
+	return false
+}
+
+// follow follows the tokens of a template json-pointer from a location of
+// the schema document. Every schema that applies to the value, through
+// "$ref" and "allOf", is stepped into. When more than one of them defines
+// the value, the definitions are combined with "allOf".
+func (r *definitionResolver) follow(loc schemaLocation, tokens []string) (schemaLocation, bool) {
+	locs := []schemaLocation{loc}
+	for _, tok := range tokens {
+		var next []schemaLocation
+		seen := make(map[string]bool)
+		for _, l := range locs {
+			for _, a := range r.applicable(l, seen) {
+				if n, ok := stepSchema(a, tok, r.syntax.FindAll(tok) != nil); ok {
+					next = append(next, n)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return schemaLocation{}, false
+		}
+		locs = next
+	}
+	if len(locs) == 1 {
+		return locs[0], true
+	}
+	allOf := make([]interface{}, len(locs))
+	for i, l := range locs {
+		allOf[i] = l.schema
+	}
+	return schemaLocation{ptr: locs[0].ptr, schema: map[string]interface{}{"allOf": allOf}}, true
+}
+
+// applicable returns the location and the schemas it applies through its
+// "$ref" and "allOf", skipping the locations already seen
+func (r *definitionResolver) applicable(loc schemaLocation, seen map[string]bool) []schemaLocation {
+	if seen[loc.ptr] {
+		return nil
+	}
+	seen[loc.ptr] = true
+	found := []schemaLocation{loc}
+	if ref, ok := loc.schema["$ref"].(string); ok {
+		if target, ok := r.lookupRef(ref); ok {
+			found = append(found, r.applicable(target, seen)...)
+		}
+	}
+	if allOf, ok := loc.schema["allOf"].([]interface{}); ok {
+		for i, v := range allOf {
+			if m, ok := v.(map[string]interface{}); ok {
+				ptr := loc.ptr + "/allOf/" + strconv.Itoa(i)
+				found = append(found, r.applicable(schemaLocation{ptr: ptr, schema: m}, seen)...)
+			}
+		}
+	}
+	return found
+}
+
+// lookupRef returns the sub schema a local "$ref", such as
+// "#/definitions/vcpuCount", refers to
+func (r *definitionResolver) lookupRef(ref string) (schemaLocation, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return schemaLocation{}, false
+	}
+	var v interface{} = r.doc
+	for _, tok := range splitPtr(ref) {
+		switch c := v.(type) {
+		case map[string]interface{}:
+			v = c[tok]
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return schemaLocation{}, false
+			}
+			v = c[i]
+		default:
+			return schemaLocation{}, false
+		}
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return schemaLocation{}, false
+	}
+	return schemaLocation{ptr: "#" + strings.TrimPrefix(ref, "#"), schema: m}, true
+}
+
+// definition returns the schema of the location to be copied into the
+// generated schema. A location that is only a "$ref" is replaced by the
+// schema it refers to. Any other local "$ref" is rewritten to refer to a
+// copy of its schema under the "definitions" of the generated schema.
+func (r *definitionResolver) definition(loc schemaLocation) map[string]interface{} {
+	seen := make(map[string]bool)
+	for len(loc.schema) == 1 && !seen[loc.ptr] {
+		seen[loc.ptr] = true
+		ref, _ := loc.schema["$ref"].(string)
+		target, ok := r.lookupRef(ref)
+		if !ok {
+			break
+		}
+		loc = target
+	}
+	return r.copyRefs(loc.schema).(map[string]interface{})
+}
+
+// copyRefs returns a deep copy of the value with its local "$ref" rewritten
+func (r *definitionResolver) copyRefs(v interface{}) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(c))
+		for _, k := range sortedKeys(c) {
+			if ref, ok := c[k].(string); ok && k == "$ref" {
+				m[k] = r.copyRef(ref)
+				continue
+			}
+			m[k] = r.copyRefs(c[k])
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(c))
+		for i := range c {
+			a[i] = r.copyRefs(c[i])
+		}
+		return a
+	}
+	return v
+}
+
+// copyRef copies the schema a local "$ref" refers to into the definitions
+// of the generated schema, and returns the "$ref" to the copy
+func (r *definitionResolver) copyRef(ref string) string {
+	target, ok := r.lookupRef(ref)
+	if !ok {
+		return ref
+	}
+	name, ok := r.names[target.ptr]
+	if !ok {
+		tokens := splitPtr(target.ptr)
+		base := "root"
+		if len(tokens) > 0 {
+			base = tokens[len(tokens)-1]
+		}
+		name = base
+		for i := 2; r.defs[name] != nil; i++ {
+			name = base + "_" + strconv.Itoa(i)
+		}
+		r.names[target.ptr] = name
+		// reserved before copying, a schema may refer to itself
+		r.defs[name] = true
+		r.defs[name] = r.copyRefs(target.schema)
+	}
+	return "#/" + KeyDefinitions + "/" + escapePtrToken(name)
+}
+
+// stepSchema returns the sub schema of a location that applies to the
+// property or array item named by tok. A parameterized key, i.e; a key
+// that holds a placeholder, names no property in particular: it is defined
+// by the only "patternProperties" of the schema, or else by its
+// "additionalProperties".
+func stepSchema(loc schemaLocation, tok string, parameterized bool) (schemaLocation, bool) {
+	sub := func(v interface{}, path ...string) (schemaLocation, bool) {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return schemaLocation{}, false
+		}
+		ptr := loc.ptr
+		for _, p := range path {
+			ptr += "/" + escapePtrToken(p)
+		}
+		return schemaLocation{ptr: ptr, schema: m}, true
+	}
+	s := loc.schema
+	if !isSchema(s) {
+		return sub(s[tok], tok)
+	}
+	if parameterized {
+		pprops, _ := s["patternProperties"].(map[string]interface{})
+		switch len(pprops) {
+		case 0:
+			return sub(s["additionalProperties"], "additionalProperties")
+		case 1:
+			pattern := sortedKeys(pprops)[0]
+			return sub(pprops[pattern], "patternProperties", pattern)
+		}
+		return schemaLocation{}, false
+	}
+
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		if l, ok := sub(props[tok], "properties", tok); ok {
+			return l, true
+		}
+	}
+	if pprops, ok := s["patternProperties"].(map[string]interface{}); ok {
+		for _, pattern := range sortedKeys(pprops) {
+			if rxp, err := regexp.Compile(pattern); err == nil && rxp.MatchString(tok) {
+				if l, ok := sub(pprops[pattern], "patternProperties", pattern); ok {
+					return l, true
+				}
+			}
+		}
+	}
+	if i, err := strconv.Atoi(tok); err == nil && i >= 0 {
+		if prefix, ok := s["prefixItems"].([]interface{}); ok && i < len(prefix) {
+			return sub(prefix[i], "prefixItems", tok)
+		}
+		switch items := s["items"].(type) {
+		case map[string]interface{}:
+			return sub(items, "items")
+		case []interface{}:
+			if i < len(items) {
+				return sub(items[i], "items", tok)
+			}
+			return sub(s["additionalItems"], "additionalItems")
+		}
+		return schemaLocation{}, false
+	}
+	return sub(s["additionalProperties"], "additionalProperties")
+}
+
+// stripPlaceholderBranches removes from the "oneOf" and "anyOf" of a
+// definition the branches that accept the placeholder itself, for e.g; the
+// {"type": "string", "pattern": "^\\$[A-Za-z][-A-Za-z0-9_]*$"} branch of a
+// schema that allows either a placeholder or a concrete value. A "oneOf" or
+// "anyOf" left with a single branch is replaced by that branch. The
+// branches of "allOf" are stripped too.
+func stripPlaceholderBranches(def map[string]interface{}, raw string) {
+	for _, kw := range []string{"oneOf", "anyOf"} {
+		branches, ok := def[kw].([]interface{})
+		if !ok {
+			continue
+		}
+		kept := make([]interface{}, 0, len(branches))
+		for _, b := range branches {
+			if !isPlaceholderBranch(b, raw) {
+				kept = append(kept, b)
+			}
+		}
+		// a definition that only accepts placeholders is left as it is
+		if len(kept) == 0 || len(kept) == len(branches) {
+			continue
+		}
+		log.WithFields(log.Fields{"placeholder": raw, "keyword": kw, "removed": len(branches) - len(kept)}).Debug()
+		def[kw] = kept
+		if len(kept) == 1 {
+			unwrapBranch(def, kw)
+		}
+	}
+	if allOf, ok := def["allOf"].([]interface{}); ok {
+		for _, b := range allOf {
+			if m, ok := b.(map[string]interface{}); ok {
+				stripPlaceholderBranches(m, raw)
+			}
+		}
+	}
+}
+
+// isPlaceholderBranch returns true if the branch is a string schema whose
+// "pattern" matches the placeholder
+func isPlaceholderBranch(branch interface{}, raw string) bool {
+	m, ok := branch.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if t, ok := m["type"]; ok && t != "string" {
+		return false
+	}
+	pattern, ok := m["pattern"].(string)
+	if !ok {
+		return false
+	}
+	rxp, err := regexp.Compile(pattern)
+	return err == nil && rxp.MatchString(raw)
+}
+
+// unwrapBranch replaces the single branch left in the keyword of a
+// definition by the keywords of the branch, unless they clash with
+// keywords of the definition
+func unwrapBranch(def map[string]interface{}, kw string) {
+	branch, ok := def[kw].([]interface{})[0].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k := range branch {
+		if _, ok := def[k]; ok {
+			return
+		}
+	}
+	delete(def, kw)
+	for k, v := range branch {
+		def[k] = v
+	}
+}
+
+// embeddedDefinition returns the definition of a placeholder embedded in a
+// longer string, derived from the definition of the string. The value of
+// the parameter is formatted as text into the string, so it must be a
+// scalar; and, if the string has a "maxLength", no longer than the text
+// around the placeholders leaves room for. If the placeholder is the only
+// one of the string, its value must also be a string that, with the text
+// around it, has the "minLength", is one of the "enum" or the "const", and
+// matches the "pattern" when its anchors and literal text allow the text
+// around the placeholder to be taken out of it, for e.g; "^sd[a-z]$" for
+// "/dev/${disk}" and "^/dev/sd[a-z]$". A diagnostic is returned for each
+// constraint of the string that can not be carried over to the placeholder.
+func embeddedDefinition(def map[string]interface{}, ph Placeholder, syntax PlaceholderSyntax) (map[string]interface{}, []Diagnostic) {
+	emb := map[string]interface{}{"type": []interface{}{"string", "number", "boolean"}}
+	var diags []Diagnostic
+	dropped := func(format string, a ...interface{}) {
+		diags = append(diags, Diagnostic{Code: DiagnosticConstraintDropped, Parameter: ph.Name,
+			Pointer: ph.Pointer, Message: fmt.Sprintf(format, a...)})
+	}
+	matches := syntax.FindAll(ph.Value)
+	literal, last := 0, 0
+	for _, m := range matches {
+		literal += utf8.RuneCountInString(syntax.Unescape(ph.Value[last:m.Start]))
+		last = m.End
+	}
+	literal += utf8.RuneCountInString(syntax.Unescape(ph.Value[last:]))
+	if max, ok := def["maxLength"].(float64); ok {
+		emb["maxLength"] = math.Max(0, max-float64(literal))
+		if len(matches) > 1 {
+			dropped("the placeholders of the string can together exceed its maxLength of %v", max)
+		}
+	}
+
+	if len(matches) != 1 {
+		for _, kw := range embeddedConstraints {
+			if _, ok := def[kw]; ok {
+				dropped("%s of the string can not be checked against each of its placeholders", kw)
+			}
+		}
+		return emb, diags
+	}
+	prefix := syntax.Unescape(ph.Value[:matches[0].Start])
+	suffix := syntax.Unescape(ph.Value[matches[0].End:])
+	if min, ok := def["minLength"].(float64); ok && min > float64(literal) {
+		emb["minLength"] = min - float64(literal)
+	}
+	if values, ok := schemaValues(def); ok {
+		enum := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			if s, ok := v.(string); ok && len(s) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(s, prefix) && strings.HasSuffix(s, suffix) {
+				enum = append(enum, s[len(prefix):len(s)-len(suffix)])
+			}
+		}
+		emb["type"] = "string"
+		emb["enum"] = enum
+	}
+	if pattern, ok := def["pattern"].(string); ok {
+		if p, ok := embeddedPattern(pattern, prefix, suffix); ok {
+			emb["type"] = "string"
+			emb["pattern"] = p
+		} else {
+			dropped("pattern %q of the string can not be checked against the placeholder, "+
+				"it is not anchored or does not start with %q and end with %q", pattern, prefix, suffix)
+		}
+	}
+	for _, kw := range embeddedConstraints {
+		switch kw {
+		case "minLength", "enum", "const", "pattern":
+		default:
+			if _, ok := def[kw]; ok {
+				dropped("%s of the string can not be checked against the placeholder", kw)
+			}
+		}
+	}
+	return emb, diags
+}
+
+// embeddedConstraints are the keywords of the definition of a string that
+// constrain it, other than "type" and "maxLength"
+var embeddedConstraints = []string{
+	"minLength", "enum", "const", "pattern", "format", "contentEncoding", "contentMediaType",
+	"$ref", "allOf", "anyOf", "oneOf", "not", "if",
+}
+
+// schemaValues returns the values allowed by the "enum" or the "const" of
+// a definition; ok is false if it has neither
+func schemaValues(def map[string]interface{}) (values []interface{}, ok bool) {
+	if c, ok := def["const"]; ok {
+		values = []interface{}{c}
+	}
+	if e, ok := def["enum"].([]interface{}); ok {
+		if values == nil {
+			return e, true
+		}
+		// a const must also be one of the enum
+		for _, v := range e {
+			if canonicalString(v) == canonicalString(values[0]) {
+				return values, true
+			}
+		}
+		return []interface{}{}, true
+	}
+	return values, values != nil
+}
+
+// embeddedPattern returns the pattern the value of a placeholder must match
+// for the string made of prefix, the value and suffix to match a pattern:
+// the pattern without prefix and suffix. ok is false if the pattern is not
+// anchored at both ends, or does not start with the literal text of prefix
+// and end with the literal text of suffix.
+func embeddedPattern(pattern, prefix, suffix string) (string, bool) {
+	re, err := resyntax.Parse(pattern, resyntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	subs := []*resyntax.Regexp{re}
+	if re.Op == resyntax.OpConcat {
+		subs = re.Sub
+	}
+	if len(subs) < 2 || subs[0].Op != resyntax.OpBeginText || subs[len(subs)-1].Op != resyntax.OpEndText {
+		return "", false
+	}
+	subs, ok := trimLiteral(subs[1:len(subs)-1], []rune(prefix), false)
+	if !ok {
+		return "", false
+	}
+	if subs, ok = trimLiteral(subs, []rune(suffix), true); !ok {
+		return "", false
+	}
+	rest := &resyntax.Regexp{Op: resyntax.OpEmptyMatch}
+	if len(subs) > 0 {
+		rest = &resyntax.Regexp{Op: resyntax.OpConcat, Sub: subs}
+	}
+	return "^(?:" + rest.String() + ")$", true
+}
+
+// trimLiteral returns the regexps of a concatenation without the literal
+// text they start with, or end with if fromEnd is true; ok is false if
+// they do not start, or end, with the literal text
+func trimLiteral(subs []*resyntax.Regexp, text []rune, fromEnd bool) ([]*resyntax.Regexp, bool) {
+	subs = append([]*resyntax.Regexp(nil), subs...)
+	for len(text) > 0 {
+		if len(subs) == 0 {
+			return nil, false
+		}
+		i := 0
+		if fromEnd {
+			i = len(subs) - 1
+		}
+		lit := subs[i]
+		if lit.Op != resyntax.OpLiteral || lit.Flags&resyntax.FoldCase != 0 {
+			return nil, false
+		}
+		n := len(lit.Rune)
+		if len(text) < n {
+			n = len(text)
+		}
+		litRunes, textRunes, rest := lit.Rune[:n], text[:n], lit.Rune[n:]
+		if fromEnd {
+			litRunes, textRunes, rest = lit.Rune[len(lit.Rune)-n:], text[len(text)-n:], lit.Rune[:len(lit.Rune)-n]
+		}
+		if string(litRunes) != string(textRunes) {
+			return nil, false
+		}
+		if fromEnd {
+			text = text[:len(text)-n]
+		} else {
+			text = text[n:]
+		}
+		switch {
+		case len(rest) == 0 && fromEnd:
+			subs = subs[:i]
+		case len(rest) == 0:
+			subs = subs[1:]
+		default:
+			trimmed := *lit
+			trimmed.Rune = rest
+			subs[i] = &trimmed
+		}
+	}
+	return subs, true
+}
+
+// keyDefinition returns the definition of a parameterized key of the
+// object defined at the location: the "propertyNames" of the object, the
+// "patternProperties" the key must match one of or, if no additional
+// properties are allowed, the names of its "properties".
+func (r *definitionResolver) keyDefinition(loc schemaLocation) map[string]interface{} {
+	var parts []interface{}
+	for _, a := range r.applicable(loc, make(map[string]bool)) {
+		if pn, ok := a.schema["propertyNames"].(map[string]interface{}); ok {
+			parts = append(parts, r.copyRefs(pn))
+		}
+		var names []interface{}
+		pprops, _ := a.schema["patternProperties"].(map[string]interface{})
+		for _, pattern := range sortedKeys(pprops) {
+			names = append(names, map[string]interface{}{"pattern": pattern})
+		}
+		if ap, ok := a.schema["additionalProperties"].(bool); ok && !ap {
+			if props, ok := a.schema["properties"].(map[string]interface{}); ok && len(props) > 0 {
+				enum := make([]interface{}, 0, len(props))
+				for _, k := range sortedKeys(props) {
+					enum = append(enum, k)
+				}
+				names = append(names, map[string]interface{}{"enum": enum})
+			}
+		} else if len(names) > 0 {
+			// any key is allowed, the patterns only select the schema of its value
+			names = nil
+		}
+		switch len(names) {
+		case 0:
+		case 1:
+			parts = append(parts, names[0])
+		default:
+			parts = append(parts, map[string]interface{}{"anyOf": names})
+		}
+	}
+	def := map[string]interface{}{"type": "string"}
+	switch len(parts) {
+	case 0:
+	case 1:
+		for k, v := range parts[0].(map[string]interface{}) {
+			def[k] = v
+		}
+	default:
+		def["allOf"] = parts
+	}
+	return def
+}
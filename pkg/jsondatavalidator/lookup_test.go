@@ -0,0 +1,395 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+var testScopedNonParamSchema = []byte(`
+{
+  "vmDeviceDefine": {
+    "vm": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string", "pattern": "^vm-"},
+        "disks": {"type": "array", "items": {"type": "object", "properties": {"size": {"type": "integer", "minimum": 1}}}}
+      }
+    },
+    "disk": {"type": "object", "properties": {"name": {"type": "string", "pattern": "^disk-"}}}
+  },
+  "volumeDeviceDefine": {
+    "disk": {"type": "object", "properties": {"name": {"type": "string", "pattern": "^vol-"}}}
+  }
+}
+`)
+
+func TestGenerateJSONSchemaFromParameterizedTemplateWithResult(t *testing.T) {
+	var regExpStr = `\$([A-Za-z][-A-Za-z0-9_]*)`
+
+	testTable := []struct {
+		description         string
+		template            []byte
+		expectedProperties  map[string]interface{}
+		expectedDiagnostics []jsondatavalidator.Diagnostic
+	}{
+		{"Resolved by location", []byte("vm:\n  name: $vmName\n  disks:\n    - size: $size\n"),
+			map[string]interface{}{
+				"vmName": map[string]interface{}{"type": "string", "pattern": "^vm-"},
+				"size":   map[string]interface{}{"type": "integer", "minimum": float64(1)},
+			}, nil},
+		{"Ambiguous definition", []byte("vm:\n  name: $vmName\ndisk:\n  name: $diskName\n"),
+			map[string]interface{}{
+				"vmName":   map[string]interface{}{"type": "string", "pattern": "^vm-"},
+				"diskName": map[string]interface{}{},
+			},
+			[]jsondatavalidator.Diagnostic{{Code: jsondatavalidator.DiagnosticAmbiguous, Parameter: "diskName", Pointer: "#/disk/name",
+				Candidates: []string{"#/vmDeviceDefine/disk/properties/name", "#/volumeDeviceDefine/disk/properties/name"},
+				Message:    "more than one definition found in the non parameterized schema"}}},
+		{"Missing definition", []byte("vm:\n  cpu: $cpu\n"),
+			map[string]interface{}{"cpu": map[string]interface{}{}},
+			[]jsondatavalidator.Diagnostic{{Code: jsondatavalidator.DiagnosticUnresolved, Parameter: "cpu", Pointer: "#/vm/cpu",
+				Message: "no definition found in the non parameterized schema"}}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(tdr.template,
+				testScopedNonParamSchema, []byte(`{"inputParam": {"type": "object"}}`), nil, regExpStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(string(res.Schema), res.Diagnostics)
+			var schema struct {
+				Properties map[string]interface{} `json:"properties"`
+			}
+			if err := json.Unmarshal(res.Schema, &schema); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(schema.Properties, tdr.expectedProperties) {
+				t.Errorf("expected properties %v, got %v", tdr.expectedProperties, schema.Properties)
+			}
+			if !reflect.DeepEqual(res.Diagnostics, tdr.expectedDiagnostics) {
+				t.Errorf("expected diagnostics %v, got %v", tdr.expectedDiagnostics, res.Diagnostics)
+			}
+
+			_, err = jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(tdr.template,
+				testScopedNonParamSchema, []byte(`{"inputParam": {"type": "object"}}`), nil, regExpStr,
+				jsondatavalidator.WithStrict())
+			t.Log(err)
+			var derr *jsondatavalidator.DiagnosticError
+			if tdr.expectedDiagnostics == nil && err != nil {
+				t.Errorf("expected no error in strict mode, got %v", err)
+			} else if tdr.expectedDiagnostics != nil && (!errors.Is(err, jsondatavalidator.ErrUnresolvedParameter) ||
+				!errors.As(err, &derr) || !reflect.DeepEqual(derr.Diagnostics, tdr.expectedDiagnostics)) {
+				t.Errorf("expected a *DiagnosticError in strict mode, got %v", err)
+			}
+		})
+	}
+}
+
+var testRefNonParamSchema = []byte(`
+{
+  "definitions": {
+    "vcpuCount": {"type": "integer", "minimum": 2, "maximum": 16},
+    "memorySize": {"type": "integer", "minimum": 512},
+    "identifier": {"type": "string", "pattern": "^[a-z]+$"},
+    "named": {"type": "object", "properties": {"name": {"$ref": "#/definitions/identifier"}}}
+  },
+  "vmDeviceDefine": {
+    "vm": {
+      "allOf": [{"$ref": "#/definitions/named"}],
+      "type": "object",
+      "properties": {
+        "vcpus": {"$ref": "#/definitions/vcpuCount"},
+        "memory": {"allOf": [{"$ref": "#/definitions/memorySize"}, {"maximum": 8192}]}
+      }
+    }
+  }
+}
+`)
+
+func TestGenerateJSONSchemaFromParameterizedTemplateWithRefs(t *testing.T) {
+	res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(
+		[]byte("vm:\n  name: $name\n  vcpus: $vcpus\n  memory: $memory\n"),
+		testRefNonParamSchema, []byte(`{"inputParam": {"type": "object"}}`), nil, `\$([A-Za-z][-A-Za-z0-9_]*)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(res.Schema))
+	expectedSchema := `{"definitions":{"memorySize":{"minimum":512,"type":"integer"}},"properties":{"memory":{"allOf":[{"$ref":"#/definitions/memorySize"},{"maximum":8192}]},"name":{"pattern":"^[a-z]+$","type":"string"},"vcpus":{"maximum":16,"minimum":2,"type":"integer"}},"required":["memory","name","vcpus"],"type":"object"}`
+	if string(res.Schema) != expectedSchema || len(res.Diagnostics) != 0 {
+		t.Fatalf("expected schema %s, got %s %v", expectedSchema, res.Schema, res.Diagnostics)
+	}
+
+	v, err := jsondatavalidator.NewValidator(bytes.NewReader(res.Schema), "inputParam.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTable := []struct {
+		description string
+		inputParams []byte
+		expectedErr error
+	}{
+		{"Valid input parameters", []byte(`{"name": "web", "vcpus": 4, "memory": 1024}`), nil},
+		{"Referred definition is applied", []byte(`{"name": "web", "vcpus": 4, "memory": 256}`), jsondatavalidator.ErrValidation},
+		{"allOf is applied", []byte(`{"name": "web", "vcpus": 4, "memory": 9000}`), jsondatavalidator.ErrValidation},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			err := v.Validate(tdr.inputParams)
+			t.Log(err)
+			if !errors.Is(err, tdr.expectedErr) {
+				t.Errorf("expected %v, got %v", tdr.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateStripsPlaceholderBranches(t *testing.T) {
+	testTable := []struct {
+		description   string
+		template      []byte
+		regExpStr     string
+		expectedVcpus string
+	}{
+		{"Placeholder branch is removed", []byte("vm:\n  vcpus: $vcpus\n"), `\$(.*)`,
+			`{"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}`},
+		{"Branch not matching the placeholder is kept", []byte("vm:\n  vcpus: >>vcpus<<\n"), `>{2}(.*)<{2}`,
+			`{"oneOf":[{"pattern":"^\\$[A-Za-z][-A-Za-z0-9_]*$","type":"string"},{"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}]}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(tdr.template,
+				testJSONParamNonParamSchema, testInputParamJSONSchema, nil, tdr.regExpStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(string(res.Schema))
+			var schema struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			}
+			if err := json.Unmarshal(res.Schema, &schema); err != nil {
+				t.Fatal(err)
+			}
+			if string(schema.Properties["vcpus"]) != tdr.expectedVcpus {
+				t.Errorf("expected vcpus %s, got %s", tdr.expectedVcpus, schema.Properties["vcpus"])
+			}
+		})
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateWithEmbeddedPlaceholders(t *testing.T) {
+	nonParamSchema := []byte(`{"vmDeviceDefine": {"vm": {"type": "object", "properties": {
+		"name": {"type": "string", "maxLength": 20},
+		"vcpus": {"type": "integer"}}}}}`)
+	res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(
+		[]byte("vm:\n  name: web-$env-${index}\n  vcpus: x$vcpus\n"), nonParamSchema,
+		[]byte(`{"inputParam": {"type": "object"}}`), nil, `\$\{?([A-Za-z][A-Za-z0-9_]*)\}?`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(res.Schema), res.Diagnostics)
+	expectedSchema := `{"properties":{"env":{"maxLength":15,"type":["string","number","boolean"]},"index":{"maxLength":15,"type":["string","number","boolean"]},"vcpus":{"type":["string","number","boolean"]}},"required":["env","index","vcpus"],"type":"object"}`
+	if string(res.Schema) != expectedSchema {
+		t.Errorf("expected schema %s", expectedSchema)
+	}
+	var codes []string
+	for _, d := range res.Diagnostics {
+		codes = append(codes, string(d.Code)+":"+d.Parameter)
+	}
+	expectedCodes := []string{"constraintDropped:env", "constraintDropped:index", "notAString:vcpus"}
+	if !reflect.DeepEqual(codes, expectedCodes) {
+		t.Errorf("expected diagnostics %v, got %v", expectedCodes, codes)
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateCarriesEmbeddedConstraints(t *testing.T) {
+	testTable := []struct {
+		description         string
+		pathDefinition      string
+		path                string
+		expectedDisk        string
+		expectedDiagnostics []string
+	}{
+		{"Pattern", `{"type": "string", "pattern": "^/dev/sd[a-z]$", "minLength": 8}`, "/dev/${disk}",
+			`{"minLength":3,"pattern":"^(?:sd[a-z])$","type":"string"}`, nil},
+		{"Pattern with a suffix", `{"type": "string", "pattern": "^/dev/[a-z]+[0-9]p1$"}`, "/dev/${disk}p1",
+			`{"pattern":"^(?:[a-z]+[0-9])$","type":"string"}`, nil},
+		{"Pattern not anchored", `{"type": "string", "pattern": "/dev/sd[a-z]"}`, "/dev/${disk}",
+			`{"type":["string","number","boolean"]}`,
+			[]string{`constraintDropped: parameter "disk" at #/vm/path: pattern "/dev/sd[a-z]" of the string can not be checked against the placeholder, it is not anchored or does not start with "/dev/" and end with ""`}},
+		{"Pattern around the placeholder", `{"type": "string", "pattern": "^/dev/sd[a-z]$"}`, "/dev/s${disk}a",
+			`{"type":["string","number","boolean"]}`,
+			[]string{`constraintDropped: parameter "disk" at #/vm/path: pattern "^/dev/sd[a-z]$" of the string can not be checked against the placeholder, it is not anchored or does not start with "/dev/s" and end with "a"`}},
+		{"Enum", `{"type": "string", "enum": ["/dev/sda", "/dev/sdb", "/tmp/sdc"]}`, "/dev/${disk}",
+			`{"enum":["sda","sdb"],"type":"string"}`, nil},
+		{"Format", `{"type": "string", "format": "uri"}`, "http://${disk}/",
+			`{"type":["string","number","boolean"]}`,
+			[]string{`constraintDropped: parameter "disk" at #/vm/path: format of the string can not be checked against the placeholder`}},
+		{"Several placeholders", `{"type": "string", "pattern": "^/dev/sd[a-z]$"}`, "/dev/${disk}${part}",
+			`{"type":["string","number","boolean"]}`,
+			[]string{`constraintDropped: parameter "disk" at #/vm/path: pattern of the string can not be checked against each of its placeholders`,
+				`constraintDropped: parameter "part" at #/vm/path: pattern of the string can not be checked against each of its placeholders`}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			nonParamSchema := []byte(fmt.Sprintf(`{"vmDeviceDefine": {"vm": {"type": "object", "properties": {"path": %s}}}}`,
+				tdr.pathDefinition))
+			res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(
+				[]byte("vm:\n  path: "+tdr.path+"\n"), nonParamSchema,
+				[]byte(`{"inputParam": {"type": "object"}}`), nil, `\$\{([A-Za-z][A-Za-z0-9_]*)\}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(string(res.Schema), res.Diagnostics)
+			var schema struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			}
+			if err := json.Unmarshal(res.Schema, &schema); err != nil {
+				t.Fatal(err)
+			}
+			if string(schema.Properties["disk"]) != tdr.expectedDisk {
+				t.Errorf("expected disk %s, got %s", tdr.expectedDisk, schema.Properties["disk"])
+			}
+			var diags []string
+			for _, d := range res.Diagnostics {
+				diags = append(diags, d.String())
+			}
+			if !reflect.DeepEqual(diags, tdr.expectedDiagnostics) {
+				t.Errorf("expected diagnostics %v, got %v", tdr.expectedDiagnostics, diags)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateWithKeysAndItems(t *testing.T) {
+	nonParamSchema := []byte(`{"vmDeviceDefine": {"vm": {"type": "object", "properties": {
+		"disks": {"type": "object", "additionalProperties": false,
+			"patternProperties": {"^sd[a-z]$": {"type": "object", "properties": {"size": {"type": "integer", "minimum": 1}}}}},
+		"labels": {"type": "object", "propertyNames": {"maxLength": 8}},
+		"nics": {"type": "array", "items": {"type": "string", "pattern": "^eth[0-9]$"}}}}}}`)
+	res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(
+		[]byte("vm:\n  disks:\n    $diskName:\n      size: $size\n  labels:\n    $label: x\n  nics:\n    - $nic\n"), nonParamSchema,
+		[]byte(`{"inputParam": {"type": "object"}}`), nil, `\$([A-Za-z][-A-Za-z0-9_]*)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(res.Schema), res.Diagnostics)
+	expectedSchema := `{"properties":{"diskName":{"pattern":"^sd[a-z]$","type":"string"},"label":{"maxLength":8,"type":"string"},"nic":{"pattern":"^eth[0-9]$","type":"string"},"size":{"minimum":1,"type":"integer"}},"required":["diskName","label","nic","size"],"type":"object"}`
+	if string(res.Schema) != expectedSchema || len(res.Diagnostics) != 0 {
+		t.Errorf("expected schema %s", expectedSchema)
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateWithSharedParameters(t *testing.T) {
+	nonParamSchema := []byte(`{"vmDeviceDefine": {
+		"vm": {"type": "object", "properties": {
+			"memory": {"type": "integer", "minimum": 512, "maximum": 16384},
+			"swap": {"type": "integer", "minimum": 256, "maximum": 4096},
+			"name": {"type": "string", "enum": ["web", "db"]}}},
+		"disk": {"type": "object", "properties": {
+			"cacheSize": {"type": "integer", "minimum": 1, "maximum": 256},
+			"label": {"type": "string", "enum": ["data", "logs"]},
+			"count": {"type": "string"}}}}}`)
+
+	testTable := []struct {
+		description         string
+		template            []byte
+		expectedSize        string
+		expectedDiagnostics []string
+	}{
+		{"Compatible definitions", []byte("vm:\n  memory: $size\n  swap: $size\n"),
+			`{"allOf":[{"maximum":16384,"minimum":512,"type":"integer"},{"maximum":4096,"minimum":256,"type":"integer"}]}`, nil},
+		{"Ranges do not overlap", []byte("vm:\n  memory: $size\ndisk:\n  cacheSize: $size\n"),
+			`{"allOf":[{"maximum":16384,"minimum":512,"type":"integer"},{"maximum":256,"minimum":1,"type":"integer"}]}`,
+			[]string{`conflict: parameter "size" at #/vm/memory: the ranges of the definitions do not overlap`}},
+		{"Types and values do not overlap", []byte("vm:\n  memory: $size\n  name: $size\ndisk:\n  label: $size\n  count: $size\n"),
+			`{"allOf":[{"maximum":16384,"minimum":512,"type":"integer"},{"enum":["web","db"],"type":"string"},{"enum":["data","logs"],"type":"string"},{"type":"string"}]}`,
+			[]string{`conflict: parameter "size" at #/vm/memory: the types of the definitions do not overlap`,
+				`conflict: parameter "size" at #/vm/memory: the allowed values of the definitions do not overlap`}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(tdr.template, nonParamSchema,
+				[]byte(`{"inputParam": {"type": "object"}}`), nil, `\$([A-Za-z][-A-Za-z0-9_]*)`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(string(res.Schema), res.Diagnostics)
+			var schema struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			}
+			if err := json.Unmarshal(res.Schema, &schema); err != nil {
+				t.Fatal(err)
+			}
+			if string(schema.Properties["size"]) != tdr.expectedSize {
+				t.Errorf("expected size %s, got %s", tdr.expectedSize, schema.Properties["size"])
+			}
+			var diags []string
+			for _, d := range res.Diagnostics {
+				if d.Code == jsondatavalidator.DiagnosticConflict {
+					diags = append(diags, d.String())
+				} else {
+					diags = append(diags, string(d.Code))
+				}
+			}
+			if !reflect.DeepEqual(diags, tdr.expectedDiagnostics) {
+				t.Errorf("expected diagnostics %v, got %v", tdr.expectedDiagnostics, diags)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONSchemaFromParameterizedTemplateWithDeclarations(t *testing.T) {
+	var regExpStr = `\$\{([^}]*)\}|\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`
+	testTable := []struct {
+		description         string
+		template            []byte
+		expectedSchema      string
+		expectedDiagnostics []string
+	}{
+		{"Default values", []byte("vm:\n  vcpus: ${vcpus:integer=2}\n  memory: $memory|1024\n"),
+			`{"properties":{"memory":{"default":1024,"maximum":16384,"minimum":512,"multipleOf":512,"type":"integer"},"vcpus":{"default":2,"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}},"required":[],"type":"object"}`,
+			nil},
+		{"Optional parameter", []byte("vm:\n  vcpus: $vcpus\n  memory: ${memory?}\n"),
+			`{"properties":{"memory":{"maximum":16384,"minimum":512,"multipleOf":512,"type":"integer"},"vcpus":{"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`,
+			nil},
+		{"Type declared on an unresolved placeholder", []byte("vm:\n  vcpus: $vcpus\n  cpu: ${cpu:string}\n"),
+			`{"properties":{"cpu":{"type":"string"},"vcpus":{"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}},"required":["cpu","vcpus"],"type":"object"}`,
+			[]string{`unresolved: parameter "cpu" at #/vm/cpu: no definition found in the non parameterized schema`}},
+		{"Declarations not allowed by the definition", []byte("vm:\n  vcpus: ${vcpus:string}\n  memory: ${memory=big}\n"),
+			`{"properties":{"memory":{"maximum":16384,"minimum":512,"multipleOf":512,"type":"integer"},"vcpus":{"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}},"required":["memory","vcpus"],"type":"object"}`,
+			[]string{`typeMismatch: parameter "vcpus" at #/vm/vcpus: declared type "string" is not allowed by the definition`,
+				`typeMismatch: parameter "memory" at #/vm/memory: default value of type "string" is not allowed by the definition`}},
+		{"Default value not valid against the definition", []byte("vm:\n  vcpus: ${vcpus=3}\n  memory: ${memory:number=1024}\n"),
+			`{"properties":{"memory":{"default":1024,"maximum":16384,"minimum":512,"multipleOf":512,"type":"integer"},"vcpus":{"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`,
+			[]string{`typeMismatch: parameter "vcpus" at #/vm/vcpus: default value 3 is not valid against the definition: 3 not multipleOf 2`}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplateWithResult(tdr.template,
+				testJSONNonParamSchema, []byte(`{"inputParam": {"type": "object"}}`), nil, regExpStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(string(res.Schema), res.Diagnostics)
+			if string(res.Schema) != tdr.expectedSchema {
+				t.Errorf("expected schema %s", tdr.expectedSchema)
+			}
+			var diags []string
+			for _, d := range res.Diagnostics {
+				diags = append(diags, d.String())
+			}
+			if !reflect.DeepEqual(diags, tdr.expectedDiagnostics) {
+				t.Errorf("expected diagnostics %v, got %v", tdr.expectedDiagnostics, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package jsondatavalidator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Mismatch is a part of a document that the parameterized template can not
+// render to, whatever the values of its parameters
+type Mismatch struct {
+	// Pointer is the json-pointer of the part of the document, for e.g;
+	// "#/vm/vcpus"
+	Pointer string `json:"pointer"`
+	// Parameter is the name of the parameter the mismatch is about, if any
+	Parameter string `json:"parameter,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (m Mismatch) String() string {
+	if m.Parameter != "" {
+		return fmt.Sprintf("%s: parameter %q: %s", m.Pointer, m.Parameter, m.Message)
+	}
+	return fmt.Sprintf("%s: %s", m.Pointer, m.Message)
+}
+
+// MatchResult is the outcome of MatchParameterizedTemplate
+type MatchResult struct {
+	// Params holds the value of each parameter the document was matched to,
+	// keyed by parameter name. An optional parameter whose member or array
+	// item is left out of the document has no value.
+	Params map[string]interface{} `json:"params"`
+	// Mismatches are the parts of the document the template can not render to
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+}
+
+// Matched returns true if the document matches the template, i.e; if
+// rendering the template with Params gives back the document
+func (res *MatchResult) Matched() bool {
+	return len(res.Mismatches) == 0
+}
+
+// MatchParameterizedTemplate takes as arguments:
+// i) a parameterized template, in json or yaml
+// ii) a document, in json or yaml, for e.g; a concrete vm definition
+// iii) regExpStr: the regexp that matches a placeholder, the same as the one
+// given to GenerateJSONSchemaFromParameterizedTemplate
+// The function matches the document against the template, the reverse of
+// RenderParameterizedTemplate, and returns the values of the parameters
+// that render the template to the document. The parts of the document that
+// do not have the structure or the literal values of the template are
+// reported as mismatches. A placeholder embedded in a longer string, for
+// e.g; "web-$env", is matched to the text between the literal parts of the
+// string, and is a string unless the placeholder declares a type.
+func MatchParameterizedTemplate(parameterizedJSON []byte, documentJSON []byte, regExpStr string) (*MatchResult, error) {
+	log.Debug()
+	syntax, err := NewRegexpSyntax(regExpStr)
+	if err != nil {
+		return nil, err
+	}
+	return MatchParameterizedTemplateWithSyntax(parameterizedJSON, documentJSON, syntax)
+}
+
+// MatchParameterizedTemplateWithSyntax is MatchParameterizedTemplate for a
+// template whose placeholders are written in the syntax
+func MatchParameterizedTemplateWithSyntax(parameterizedJSON []byte, documentJSON []byte,
+	syntax PlaceholderSyntax) (*MatchResult, error) {
+	log.Debug()
+	tmpl, err := parseTemplate(parameterizedJSON, syntax)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJSONBuf(documentJSON)
+	if err != nil {
+		return nil, err
+	}
+	m := &matcher{tmpl: tmpl, syntax: syntax, params: make(map[string]interface{}),
+		bound: make(map[string]string)}
+	res := &MatchResult{Params: m.params}
+	if tmpl.root == nil {
+		if doc != nil {
+			res.Mismatches = []Mismatch{{Pointer: "#", Message: "expected an empty document"}}
+		}
+		return res, nil
+	}
+	res.Mismatches = m.match(tmpl.root, doc, "#")
+	log.WithFields(log.Fields{"MatchResult": res}).Debug()
+	return res, nil
+}
+
+// matcher holds what is needed to match the nodes of a template against a
+// document, and the values of the parameters matched so far
+type matcher struct {
+	tmpl   *parsedTemplate
+	syntax PlaceholderSyntax
+	params map[string]interface{}
+	// bound maps the name of each matched parameter to the pointer it was
+	// matched at
+	bound map[string]string
+}
+
+// clone returns a copy of the matcher, for a match that may be dropped
+func (m *matcher) clone() *matcher {
+	c := &matcher{tmpl: m.tmpl, syntax: m.syntax, params: make(map[string]interface{}, len(m.params)),
+		bound: make(map[string]string, len(m.bound))}
+	for k, v := range m.params {
+		c.params[k] = v
+	}
+	for k, v := range m.bound {
+		c.bound[k] = v
+	}
+	return c
+}
+
+// adopt replaces the values of the matcher by those of a clone
+func (m *matcher) adopt(c *matcher) {
+	for k, v := range c.params {
+		m.params[k] = v
+	}
+	for k, v := range c.bound {
+		m.bound[k] = v
+	}
+}
+
+// match returns the mismatches between a node of the template and the
+// value of the document at the pointer
+func (m *matcher) match(n *yamlv3.Node, v interface{}, ptr string) []Mismatch {
+	n = resolveAlias(n)
+	switch n.Kind {
+	case yamlv3.MappingNode:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return []Mismatch{{Pointer: ptr, Message: "expected an object, got " + jsonType(v)}}
+		}
+		return m.matchObject(n, obj, ptr)
+	case yamlv3.SequenceNode:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return []Mismatch{{Pointer: ptr, Message: "expected an array, got " + jsonType(v)}}
+		}
+		return m.matchArray(n, arr, ptr)
+	case yamlv3.ScalarNode:
+		if n.Tag == "!!str" {
+			return m.matchString(m.tmpl.value(n), v, ptr)
+		}
+		want, err := scalarValue(n)
+		if err != nil {
+			return []Mismatch{{Pointer: ptr, Message: err.Error()}}
+		}
+		if canonicalString(normalizeValue(want)) != canonicalString(v) {
+			return []Mismatch{{Pointer: ptr, Message: fmt.Sprintf("expected %s, got %s",
+				canonicalString(normalizeValue(want)), canonicalString(v))}}
+		}
+	}
+	return nil
+}
+
+// matchObject matches the members of a mapping node. The members whose key
+// holds a placeholder are matched to the members of the object that are
+// not members of the template, in the order of their keys.
+func (m *matcher) matchObject(n *yamlv3.Node, obj map[string]interface{}, ptr string) []Mismatch {
+	var mismatches []Mismatch
+	rest := make(map[string]bool, len(obj))
+	for k := range obj {
+		rest[k] = true
+	}
+	var parameterized []int
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := m.tmpl.value(n.Content[i])
+		if n.Content[i].Tag == "!!str" && m.syntax.FindAll(key) != nil {
+			parameterized = append(parameterized, i)
+			continue
+		}
+		if n.Content[i].Tag == "!!str" {
+			key = m.syntax.Unescape(key)
+		}
+		keyPtr := ptr + "/" + escapePtrToken(key)
+		v, ok := obj[key]
+		if !ok {
+			if !m.omittable(n.Content[i+1]) {
+				mismatches = append(mismatches, Mismatch{Pointer: keyPtr, Message: "missing member"})
+			}
+			continue
+		}
+		delete(rest, key)
+		mismatches = append(mismatches, m.match(n.Content[i+1], v, keyPtr)...)
+	}
+
+	for _, i := range parameterized {
+		tmplKey := m.tmpl.value(n.Content[i])
+		matched := false
+		for _, k := range sortedSetKeys(rest) {
+			keyPtr := ptr + "/" + escapePtrToken(k)
+			c := m.clone()
+			if c.matchString(tmplKey, k, keyPtr) != nil || c.match(n.Content[i+1], obj[k], keyPtr) != nil {
+				continue
+			}
+			m.adopt(c)
+			delete(rest, k)
+			matched = true
+			break
+		}
+		if !matched && !m.omittable(n.Content[i]) && !m.omittable(n.Content[i+1]) {
+			mismatches = append(mismatches, Mismatch{Pointer: ptr + "/" + escapePtrToken(tmplKey),
+				Message: "no member matches the parameterized key"})
+		}
+	}
+
+	for _, k := range sortedSetKeys(rest) {
+		mismatches = append(mismatches, Mismatch{Pointer: ptr + "/" + escapePtrToken(k), Message: "unexpected member"})
+	}
+	return mismatches
+}
+
+// matchArray matches the items of a sequence node. Items that hold an
+// optional parameter are left out of the match if the array has fewer
+// items than the template.
+func (m *matcher) matchArray(n *yamlv3.Node, arr []interface{}, ptr string) []Mismatch {
+	omit := len(n.Content) - len(arr)
+	var mismatches []Mismatch
+	j := 0
+	for _, c := range n.Content {
+		if omit > 0 && m.omittable(c) {
+			omit--
+			continue
+		}
+		if j >= len(arr) {
+			break
+		}
+		mismatches = append(mismatches, m.match(c, arr[j], ptr+"/"+strconv.Itoa(j))...)
+		j++
+	}
+	switch {
+	case j < len(arr):
+		mismatches = append(mismatches, Mismatch{Pointer: ptr,
+			Message: fmt.Sprintf("expected %d items, got %d", j, len(arr))})
+	case omit > 0:
+		mismatches = append(mismatches, Mismatch{Pointer: ptr,
+			Message: fmt.Sprintf("expected %d items, got %d", len(n.Content), len(arr))})
+	}
+	return mismatches
+}
+
+// omittable returns true if the node is a string that holds an optional
+// placeholder, so that it may be left out of the rendered document
+func (m *matcher) omittable(n *yamlv3.Node) bool {
+	n = resolveAlias(n)
+	if n.Kind != yamlv3.ScalarNode || n.Tag != "!!str" {
+		return false
+	}
+	for _, pm := range m.syntax.FindAll(m.tmpl.value(n)) {
+		if parseParameter(pm.Text).optional {
+			return true
+		}
+	}
+	return false
+}
+
+// matchString matches a string of the template. A string that is the
+// placeholder alone matches any value; otherwise the value must be a
+// string made of the literal parts of the template string.
+func (m *matcher) matchString(s string, v interface{}, ptr string) []Mismatch {
+	matches := m.syntax.FindAll(s)
+	if len(matches) == 1 && matches[0].Start == 0 && matches[0].End == len(s) {
+		return m.bind(parseParameter(matches[0].Text), v, ptr)
+	}
+	str, ok := v.(string)
+	if !ok {
+		return []Mismatch{{Pointer: ptr, Message: "expected a string, got " + jsonType(v)}}
+	}
+	if matches == nil {
+		if want := m.syntax.Unescape(s); str != want {
+			return []Mismatch{{Pointer: ptr, Message: fmt.Sprintf("expected %q, got %q", want, str)}}
+		}
+		return nil
+	}
+
+	// the literal parts of the string, with a group for each placeholder
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, pm := range matches {
+		expr.WriteString(regexp.QuoteMeta(m.syntax.Unescape(s[last:pm.Start])))
+		expr.WriteString("(.*?)")
+		last = pm.End
+	}
+	expr.WriteString(regexp.QuoteMeta(m.syntax.Unescape(s[last:])))
+	expr.WriteString("$")
+	res := regexp.MustCompile(expr.String()).FindStringSubmatch(str)
+	if res == nil {
+		return []Mismatch{{Pointer: ptr, Message: fmt.Sprintf("%q does not match %q", str, s)}}
+	}
+	var mismatches []Mismatch
+	for i, pm := range matches {
+		spec := parseParameter(pm.Text)
+		var pv interface{} = res[i+1]
+		if spec.typ != "" {
+			pv = parseDefault(res[i+1], spec.typ)
+		}
+		mismatches = append(mismatches, m.bind(spec, pv, ptr)...)
+	}
+	return mismatches
+}
+
+// bind sets the value of a parameter, that must be the same at every
+// place the parameter is used
+func (m *matcher) bind(spec parameterSpec, v interface{}, ptr string) []Mismatch {
+	if prev, ok := m.params[spec.name]; ok {
+		if canonicalString(prev) != canonicalString(v) {
+			return []Mismatch{{Pointer: ptr, Parameter: spec.name,
+				Message: fmt.Sprintf("value %s differs from %s matched at %s",
+					canonicalString(v), canonicalString(prev), m.bound[spec.name])}}
+		}
+		return nil
+	}
+	if spec.typ != "" && isJSONType(spec.typ) {
+		if t := jsonType(v); t != spec.typ && !(t == "integer" && spec.typ == "number") {
+			return []Mismatch{{Pointer: ptr, Parameter: spec.name,
+				Message: fmt.Sprintf("value of type %q is not of the declared type %q", t, spec.typ)}}
+		}
+	}
+	m.params[spec.name] = v
+	m.bound[spec.name] = ptr
+	return nil
+}
+
+// sortedSetKeys returns the keys of a set in order
+func sortedSetKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
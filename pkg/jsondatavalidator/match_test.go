@@ -0,0 +1,143 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestMatchParameterizedTemplate(t *testing.T) {
+	var regExpStr = `\$([A-Za-z][A-Za-z0-9_]*\??)`
+	var testParameterizedData = []byte(`
+vm:
+  name: web-$env-$index
+  vcpus: $vcpus
+  memory: $memory?
+  enabled: true
+  disks:
+    $diskName:
+      size: $size
+  nics:
+    - $nic
+    - $backupNic?
+`)
+
+	testTable := []struct {
+		description        string
+		document           []byte
+		expectedParams     map[string]interface{}
+		expectedMismatches []string
+	}{
+		{"Every parameter", []byte(`{"vm": {"name": "web-prod-1", "vcpus": 4, "memory": 1024, "enabled": true,
+			"disks": {"sda": {"size": 10}}, "nics": ["eth0", "eth1"]}}`),
+			map[string]interface{}{"env": "prod", "index": "1", "vcpus": float64(4), "memory": float64(1024),
+				"diskName": "sda", "size": float64(10), "nic": "eth0", "backupNic": "eth1"}, nil},
+		{"Optional parameters left out", []byte(`{"vm": {"name": "web-dev-2", "vcpus": {"min": 2}, "enabled": true,
+			"disks": {"sdb": {"size": 20}}, "nics": ["eth0"]}}`),
+			map[string]interface{}{"env": "dev", "index": "2", "vcpus": map[string]interface{}{"min": float64(2)},
+				"diskName": "sdb", "size": float64(20), "nic": "eth0"}, nil},
+		{"Literal values differ", []byte(`{"vm": {"name": "db-prod-1", "vcpus": 4, "enabled": false,
+			"disks": {"sda": {"size": 10}}, "nics": ["eth0"]}}`),
+			map[string]interface{}{"vcpus": float64(4), "diskName": "sda", "size": float64(10), "nic": "eth0"},
+			[]string{
+				`#/vm/name: "db-prod-1" does not match "web-$env-$index"`,
+				"#/vm/enabled: expected true, got false",
+			}},
+		{"Structure differs", []byte(`{"vm": {"name": "web-prod-1", "cpus": 4, "enabled": true,
+			"disks": [], "nics": ["eth0", "eth1", "eth2"]}}`),
+			map[string]interface{}{"env": "prod", "index": "1", "nic": "eth0", "backupNic": "eth1"},
+			[]string{
+				"#/vm/vcpus: missing member",
+				"#/vm/disks: expected an object, got array",
+				"#/vm/nics: expected 2 items, got 3",
+				"#/vm/cpus: unexpected member",
+			}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := jsondatavalidator.MatchParameterizedTemplate(testParameterizedData, tdr.document, regExpStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(res)
+			if !reflect.DeepEqual(tdr.expectedParams, res.Params) {
+				t.Errorf("expected %v, got %v", tdr.expectedParams, res.Params)
+			}
+			var mismatches []string
+			for _, m := range res.Mismatches {
+				mismatches = append(mismatches, m.String())
+			}
+			if !reflect.DeepEqual(tdr.expectedMismatches, mismatches) {
+				t.Errorf("expected %v, got %v", tdr.expectedMismatches, mismatches)
+			}
+			if !res.Matched() {
+				return
+			}
+			// rendering the template with the matched parameters gives back the document
+			out, err := jsondatavalidator.RenderParameterizedTemplate(testParameterizedData, regExpStr, res.Params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, _ := jsondatavalidator.CanonicalizeJSON(tdr.document)
+			rendered, _ := jsondatavalidator.CanonicalizeJSON(out)
+			if string(expected) != string(rendered) {
+				t.Errorf("expected %s, got %s", expected, rendered)
+			}
+		})
+	}
+}
+
+func TestMatchParameterizedTemplateSharedParameters(t *testing.T) {
+	testTable := []struct {
+		description        string
+		document           []byte
+		expectedMismatches []string
+	}{
+		{"Same value", []byte(`{"name": "web", "host": "web.example.com", "index": 1}`), nil},
+		{"Different values", []byte(`{"name": "web", "host": "db.example.com", "index": 1}`),
+			[]string{`#/host: parameter "name": value "db" differs from "web" matched at #/name`}},
+		{"Declared type", []byte(`{"name": "web", "host": "web.example.com", "index": "1"}`),
+			[]string{`#/index: parameter "index": value of type "string" is not of the declared type "integer"`}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := jsondatavalidator.MatchParameterizedTemplate(
+				[]byte(`{"name": "${name}", "host": "${name}.example.com", "index": "${index:integer}"}`),
+				tdr.document, `\$\{([^}]*)\}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var mismatches []string
+			for _, m := range res.Mismatches {
+				mismatches = append(mismatches, m.String())
+			}
+			if !reflect.DeepEqual(tdr.expectedMismatches, mismatches) {
+				t.Errorf("expected %v, got %v", tdr.expectedMismatches, mismatches)
+			}
+		})
+	}
+}
+
+func TestMatchParameterizedTemplateErrors(t *testing.T) {
+	testTable := []struct {
+		description string
+		template    []byte
+		document    []byte
+		regExpStr   string
+	}{
+		{"Invalid regexp", []byte(`{"vm": "$vm"}`), []byte(`{"vm": 1}`), `\$(`},
+		{"Invalid template", []byte("vm: [1, 2"), []byte(`{"vm": 1}`), `\$(.*)`},
+		{"Invalid document", []byte(`{"vm": "$vm"}`), []byte("vm: [1, 2"), `\$(.*)`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			if _, err := jsondatavalidator.MatchParameterizedTemplate(tdr.template, tdr.document, tdr.regExpStr); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
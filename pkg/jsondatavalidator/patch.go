@@ -0,0 +1,320 @@
+package jsondatavalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MergePatch takes as arguments:
+// i) a json document
+// ii) patchJSON: an RFC 7396 JSON Merge Patch
+// The function returns the patched document. The members of an object of
+// the patch are merged into the object of the document at the same place,
+// recursively; a null member removes the member of the document; any
+// other value, arrays included, replaces the value of the document as a
+// whole. A patch that is not an object replaces the document.
+func MergePatch(docJSON, patchJSON []byte) ([]byte, error) {
+	log.Debug()
+	var doc, patch interface{}
+	if err := json.Unmarshal(docJSON, &doc); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	if err := json.Unmarshal(patchJSON, &patch); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	return json.Marshal(mergePatch(doc, patch, false))
+}
+
+// mergePatch merges a patch into a decoded json value, as RFC 7396 does.
+// If keepNull is true, a null member of the patch is merged as a value
+// instead of removing the member of the target.
+func mergePatch(target, patch interface{}, keepNull bool) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{}, len(p))
+	}
+	for k, v := range p {
+		if v == nil && !keepNull {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v, keepNull)
+	}
+	return t
+}
+
+// JSON Patch operations
+const (
+	patchAdd     = "add"
+	patchRemove  = "remove"
+	patchReplace = "replace"
+	patchMove    = "move"
+	patchCopy    = "copy"
+	patchTest    = "test"
+)
+
+// patchOperation is an operation of an RFC 6902 JSON Patch
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch takes as arguments:
+// i) a json document
+// ii) patchJSON: an RFC 6902 JSON Patch, i.e; an array of operations, for
+// e.g; [{"op": "replace", "path": "/vm/vcpus/maximum", "value": 4}]
+// The function applies the operations in order and returns the patched
+// document. The paths are RFC 6901 json-pointers, without the leading "#".
+// If an operation fails, for e.g; a "test" whose value differs or a path
+// that does not exist, a *PatchError is returned and the document is not
+// patched.
+func ApplyJSONPatch(docJSON, patchJSON []byte) ([]byte, error) {
+	log.Debug()
+	var doc interface{}
+	if err := json.Unmarshal(docJSON, &doc); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	var ops []patchOperation
+	if err := json.Unmarshal(patchJSON, &ops); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	doc, err := applyJSONPatch(doc, ops)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// applyJSONPatch applies the operations of a JSON Patch to a decoded json value
+func applyJSONPatch(doc interface{}, ops []patchOperation) (interface{}, error) {
+	for i, op := range ops {
+		var err error
+		if doc, err = applyPatchOperation(doc, op); err != nil {
+			path := ""
+			if op.Path != nil {
+				path = *op.Path
+			}
+			log.WithFields(log.Fields{"PatchError": err, "Operation": i}).Error()
+			return nil, &PatchError{Index: i, Op: op.Op, Path: path, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+// applyPatchOperation applies an operation of a JSON Patch
+func applyPatchOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("missing path")
+	}
+	path, err := parsePatchPointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	switch op.Op {
+	case patchAdd, patchReplace, patchTest:
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		if err := json.Unmarshal(*op.Value, &value); err != nil {
+			return nil, err
+		}
+	case patchMove, patchCopy:
+		if op.From == nil {
+			return nil, fmt.Errorf("missing from")
+		}
+		from, err := parsePatchPointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = getPointer(doc, from); err != nil {
+			return nil, err
+		}
+		if op.Op == patchCopy {
+			value = normalizeValue(value)
+			break
+		}
+		if strings.HasPrefix(*op.Path+"/", *op.From+"/") && *op.Path != *op.From {
+			return nil, fmt.Errorf("can not move %q into one of its children", *op.From)
+		}
+		if doc, err = removePointer(doc, from); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case patchAdd, patchMove, patchCopy:
+		return addPointer(doc, path, value)
+	case patchRemove:
+		return removePointer(doc, path)
+	case patchReplace:
+		if _, err := getPointer(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = removePointer(doc, path); err != nil {
+			return nil, err
+		}
+		return addPointer(doc, path, value)
+	case patchTest:
+		v, err := getPointer(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if canonicalString(v) != canonicalString(value) {
+			return nil, fmt.Errorf("value is %s, not %s", canonicalString(v), canonicalString(value))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePatchPointer splits an RFC 6901 json-pointer into its unescaped tokens
+func parsePatchPointer(ptr string) ([]string, error) {
+	if ptr != "" && !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("json-pointer %q does not start with \"/\"", ptr)
+	}
+	if ptr == "" {
+		return nil, nil
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// arrayIndex returns the index of an array a token refers to. end allows
+// the index past the last item, and "-", that refers to it.
+func arrayIndex(tok string, length int, end bool) (int, error) {
+	if tok == "-" && end {
+		return length, nil
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	if i > length || (i == length && !end) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+// getPointer returns the value at the tokens of a json-pointer
+func getPointer(doc interface{}, tokens []string) (interface{}, error) {
+	v := doc
+	for _, tok := range tokens {
+		switch c := v.(type) {
+		case map[string]interface{}:
+			child, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", tok)
+			}
+			v = child
+		case []interface{}:
+			i, err := arrayIndex(tok, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			v = c[i]
+		default:
+			return nil, fmt.Errorf("%q can not be looked up in a %s", tok, jsonType(v))
+		}
+	}
+	return v, nil
+}
+
+// updateParent returns the document with the parent of the value at the
+// tokens of a json-pointer replaced by the result of fn
+func updateParent(doc interface{}, tokens []string,
+	fn func(parent interface{}, tok string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		child, ok := c[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", tokens[0])
+		}
+		child, err := updateParent(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[tokens[0]] = child
+		return c, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(c), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := updateParent(c[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	}
+	return nil, fmt.Errorf("%q can not be looked up in a %s", tokens[0], jsonType(doc))
+}
+
+// addPointer adds a value at the tokens of a json-pointer: a member of an
+// object is set, an item is inserted into an array
+func addPointer(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent interface{}, tok string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			c[tok] = value
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(tok, len(c), true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("%q can not be added to a %s", tok, jsonType(parent))
+	})
+}
+
+// removePointer removes the value at the tokens of a json-pointer
+func removePointer(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the whole document can not be removed")
+	}
+	return updateParent(doc, tokens, func(parent interface{}, tok string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := c[tok]; !ok {
+				return nil, fmt.Errorf("member %q not found", tok)
+			}
+			delete(c, tok)
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(tok, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%q can not be removed from a %s", tok, jsonType(parent))
+	})
+}
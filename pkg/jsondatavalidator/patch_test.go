@@ -0,0 +1,146 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestMergePatch(t *testing.T) {
+	testTable := []struct {
+		description    string
+		doc, patch     string
+		expectedOutput string
+	}{
+		{"Member replaced", `{"a": "b"}`, `{"a": "c"}`, `{"a":"c"}`},
+		{"Member added", `{"a": "b"}`, `{"b": "c"}`, `{"a":"b","b":"c"}`},
+		{"Member removed", `{"a": "b", "b": "c"}`, `{"a": null}`, `{"b":"c"}`},
+		{"Array replaced", `{"a": ["b"]}`, `{"a": ["c", "d"]}`, `{"a":["c","d"]}`},
+		{"Nested objects merged", `{"a": {"b": "c", "d": "e"}}`, `{"a": {"b": "f", "d": null}}`, `{"a":{"b":"f"}}`},
+		{"Object replaces a value", `{"a": "b"}`, `{"a": {"c": null}}`, `{"a":{}}`},
+		{"Patch that is not an object", `{"a": "b"}`, `["c"]`, `["c"]`},
+		{"Document that is not an object", `["a"]`, `{"a": "b"}`, `{"a":"b"}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			out, err := jsondatavalidator.MergePatch([]byte(tdr.doc), []byte(tdr.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s, got %s", tdr.expectedOutput, out)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	testTable := []struct {
+		description    string
+		doc, patch     string
+		expectedOutput string
+	}{
+		{"Add a member", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"Add an item", `{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"Append an item", `{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": "qux"}]`, `{"foo":["bar","qux"]}`},
+		{"Remove an item", `{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"Replace a member", `{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"Move a member", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"Move an item", `{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`},
+		{"Copy a member", `{"a": {"b": 1}}`, `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`,
+			`{"a":{"b":1},"c":{"b":2}}`},
+		{"Test then replace", `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2.0}, {"op": "replace", "path": "", "value": 1}]`,
+			`1`},
+		{"Escaped pointer", `{"a/b": {"m~n": 1}}`, `[{"op": "replace", "path": "/a~1b/m~0n", "value": 2}]`, `{"a/b":{"m~n":2}}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			out, err := jsondatavalidator.ApplyJSONPatch([]byte(tdr.doc), []byte(tdr.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s, got %s", tdr.expectedOutput, out)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	testTable := []struct {
+		description string
+		doc, patch  string
+		expectedErr error
+	}{
+		{"Test fails", `{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, jsondatavalidator.ErrPatch},
+		{"Missing member", `{"baz": "qux"}`, `[{"op": "remove", "path": "/foo"}]`, jsondatavalidator.ErrPatch},
+		{"Replace of a missing member", `{"baz": "qux"}`, `[{"op": "replace", "path": "/foo", "value": 1}]`, jsondatavalidator.ErrPatch},
+		{"Index out of range", `{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/2", "value": 1}]`, jsondatavalidator.ErrPatch},
+		{"Leading zero in an index", `{"foo": ["bar", "baz"]}`, `[{"op": "remove", "path": "/foo/01"}]`, jsondatavalidator.ErrPatch},
+		{"Move into a child", `{"foo": {"bar": 1}}`, `[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`, jsondatavalidator.ErrPatch},
+		{"Unknown operation", `{}`, `[{"op": "merge", "path": "/foo"}]`, jsondatavalidator.ErrPatch},
+		{"Missing value", `{}`, `[{"op": "add", "path": "/foo"}]`, jsondatavalidator.ErrPatch},
+		{"Patch that is not an array", `{}`, `{"op": "add"}`, jsondatavalidator.ErrUnmarshal},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			_, err := jsondatavalidator.ApplyJSONPatch([]byte(tdr.doc), []byte(tdr.patch))
+			t.Log(err)
+			if !errors.Is(err, tdr.expectedErr) || !errors.Is(err, jsondatavalidator.ErrInvalidInput) {
+				t.Errorf("expected %v, got %v", tdr.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONSchemaWithOverlays(t *testing.T) {
+	var regExpStr = `\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`
+	var testTemplate = []byte("vm:\n  vcpus: $vcpus\n  name: $name|null\n")
+	var testNonParamSchema = []byte(`{"vmDeviceDefine": {"vm": {"properties": {
+		"vcpus": {"type": "integer", "minimum": 2, "maximum": 16}, "name": {"type": ["string", "null"]}}}}}`)
+
+	testTable := []struct {
+		description    string
+		opts           []jsondatavalidator.GenerateOption
+		expectedOutput string
+	}{
+		{"No overlay", nil,
+			`{"properties":{"name":{"default":null,"type":["string","null"]},"vcpus":{"maximum":16,"minimum":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`},
+		{"Merge patch", []jsondatavalidator.GenerateOption{
+			jsondatavalidator.WithMergePatch([]byte(`{"properties": {"vcpus": {"maximum": 4}, "name": {"default": null}}}`))},
+			`{"properties":{"name":{"type":["string","null"]},"vcpus":{"maximum":4,"minimum":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`},
+		{"Merge patch then JSON patch", []jsondatavalidator.GenerateOption{
+			jsondatavalidator.WithMergePatch([]byte(`{"properties": {"vcpus": {"maximum": 4}}}`)),
+			jsondatavalidator.WithJSONPatch([]byte(`[{"op": "test", "path": "/properties/vcpus/maximum", "value": 4},
+				{"op": "add", "path": "/required/-", "value": "name"}]`))},
+			`{"properties":{"name":{"default":null,"type":["string","null"]},"vcpus":{"maximum":4,"minimum":2,"type":"integer"}},"required":["vcpus","name"],"type":"object"}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			opts := append([]jsondatavalidator.GenerateOption{jsondatavalidator.WithCanonicalJSON()}, tdr.opts...)
+			out, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(testTemplate, testNonParamSchema,
+				[]byte(`{"inputParam": {"type": "object"}}`), nil, regExpStr, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s, got %s", tdr.expectedOutput, out)
+			}
+		})
+	}
+
+	_, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(testTemplate, testNonParamSchema,
+		[]byte(`{"inputParam": {"type": "object"}}`), nil, regExpStr,
+		jsondatavalidator.WithJSONPatch([]byte(`[{"op": "remove", "path": "/properties/memory"}]`)))
+	if !errors.Is(err, jsondatavalidator.ErrPatch) {
+		t.Errorf("expected a patch error, got %v", err)
+	}
+}
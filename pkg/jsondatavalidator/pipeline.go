@@ -0,0 +1,176 @@
+package jsondatavalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Stage identifies a step of ValidateAndRenderParameterizedTemplate
+type Stage string
+
+const (
+	// StageGenerateSchema generates the inputParam schema from the template
+	StageGenerateSchema Stage = "generateSchema"
+	// StageValidateInputParams validates the input parameters against the
+	// generated inputParam schema
+	StageValidateInputParams Stage = "validateInputParams"
+	// StageRender substitutes the input parameters into the template
+	StageRender Stage = "render"
+	// StageValidateRendered validates the rendered document against the
+	// non parameterized device schema
+	StageValidateRendered Stage = "validateRendered"
+)
+
+// defaultDeviceSchemaURL is the url the device schema is loaded as when
+// RenderRequest.DeviceSchemaURL is empty
+const defaultDeviceSchemaURL = "schema.json"
+
+// RenderRequest holds the arguments of ValidateAndRenderParameterizedTemplate
+type RenderRequest struct {
+	// ParameterizedJSON is the parameterized template, in json or yaml
+	ParameterizedJSON []byte
+	// NonParamDefineJSONBuf, InputParamSchemaJSONBuf, KeysToAddToRequiredSection
+	// and RegExpStr are passed to GenerateJSONSchemaFromParameterizedTemplateWithResult
+	NonParamDefineJSONBuf      []byte
+	InputParamSchemaJSONBuf    []byte
+	KeysToAddToRequiredSection []string
+	RegExpStr                  string
+	// Syntax, if set, is the syntax of the placeholders of the template,
+	// used instead of RegExpStr
+	Syntax PlaceholderSyntax
+	// GenerateOptions configure the generation of the inputParam schema
+	GenerateOptions []GenerateOption
+	// InputParams holds the value of each parameter, in json or yaml
+	InputParams []byte
+	// DeviceValidator validates the rendered document. If nil, a validator
+	// is compiled from DeviceSchemaJSONBuf, loaded as DeviceSchemaURL.
+	DeviceValidator     *Validator
+	DeviceSchemaJSONBuf []byte
+	DeviceSchemaURL     string
+}
+
+// RenderReport is the outcome of ValidateAndRenderParameterizedTemplate.
+// Fields of the stages that were not reached are left empty.
+type RenderReport struct {
+	// FailedStage is the stage that failed, empty if every stage succeeded
+	FailedStage Stage `json:"failedStage,omitempty"`
+	// InputParamSchema is the generated inputParam schema
+	InputParamSchema json.RawMessage `json:"inputParamSchema,omitempty"`
+	// Diagnostics are the problems found while generating the inputParam schema
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// InputParamsResult holds the violations of the input parameters
+	InputParamsResult *ValidationResult `json:"inputParamsResult,omitempty"`
+	// Rendered is the rendered document, as json. It is set once the
+	// template is rendered, even if the document then fails validation.
+	Rendered json.RawMessage `json:"rendered,omitempty"`
+	// RenderedResult holds the violations of the rendered document
+	RenderedResult *ValidationResult `json:"renderedResult,omitempty"`
+}
+
+// Succeeded returns true if every stage succeeded
+func (rep *RenderReport) Succeeded() bool {
+	return rep.FailedStage == ""
+}
+
+// StageError is returned by ValidateAndRenderParameterizedTemplate when a
+// stage fails. It matches, using errors.Is and errors.As, the error of the
+// stage.
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Stage, e.Err)
+}
+
+// Unwrap returns the error of the stage
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateAndRenderParameterizedTemplate runs, in order, the stages:
+// i) generate the inputParam schema of the template
+// ii) validate the input parameters against the inputParam schema
+// iii) render the template with the input parameters
+// iv) validate the rendered document against the device schema
+// It stops at the first stage that fails. The report is always returned;
+// the error, a *StageError, is non nil if a stage failed. A failed
+// validation stage returns a *ValidationError.
+func ValidateAndRenderParameterizedTemplate(req *RenderRequest) (*RenderReport, error) {
+	log.Debug()
+	rep := &RenderReport{}
+	fail := func(stage Stage, err error) (*RenderReport, error) {
+		log.WithFields(log.Fields{"Stage": stage, "Error": err}).Error()
+		rep.FailedStage = stage
+		return rep, &StageError{Stage: stage, Err: err}
+	}
+
+	syntax := req.Syntax
+	if syntax == nil {
+		var err error
+		if syntax, err = NewRegexpSyntax(req.RegExpStr); err != nil {
+			return fail(StageGenerateSchema, err)
+		}
+	}
+	opts := append([]GenerateOption{WithPlaceholderSyntax(syntax)}, req.GenerateOptions...)
+	gen, err := GenerateJSONSchemaFromParameterizedTemplateWithResult(req.ParameterizedJSON,
+		req.NonParamDefineJSONBuf, req.InputParamSchemaJSONBuf,
+		req.KeysToAddToRequiredSection, req.RegExpStr, opts...)
+	if err != nil {
+		return fail(StageGenerateSchema, err)
+	}
+	schema := gen.Schema
+	rep.InputParamSchema = schema
+	rep.Diagnostics = gen.Diagnostics
+
+	inputValidator, err := NewValidator(bytes.NewReader(schema), KeyInputParam+".json")
+	if err != nil {
+		return fail(StageGenerateSchema, err)
+	}
+	rep.InputParamsResult, err = inputValidator.ValidateWithResult(req.InputParams)
+	if err != nil {
+		return fail(StageValidateInputParams, err)
+	}
+	if !rep.InputParamsResult.Valid() {
+		return fail(StageValidateInputParams, &ValidationError{Result: rep.InputParamsResult})
+	}
+
+	inputParams, err := decodeJSONBuf(req.InputParams)
+	if err != nil {
+		return fail(StageValidateInputParams, err)
+	}
+	values, _ := inputParams.(map[string]interface{})
+	doc, err := renderParameterizedTemplate(req.ParameterizedJSON, syntax, values)
+	if err != nil {
+		return fail(StageRender, err)
+	}
+	rendered, err := json.Marshal(doc)
+	if err != nil {
+		return fail(StageRender, err)
+	}
+	rep.Rendered = rendered
+
+	deviceValidator := req.DeviceValidator
+	if deviceValidator == nil {
+		url := req.DeviceSchemaURL
+		if url == "" {
+			url = defaultDeviceSchemaURL
+		}
+		deviceValidator, err = NewValidator(bytes.NewReader(req.DeviceSchemaJSONBuf), url)
+		if err != nil {
+			return fail(StageValidateRendered, err)
+		}
+	}
+	rep.RenderedResult, err = deviceValidator.ValidateWithResult(rendered)
+	if err != nil {
+		return fail(StageValidateRendered, err)
+	}
+	if !rep.RenderedResult.Valid() {
+		return fail(StageValidateRendered, &ValidationError{Result: rep.RenderedResult})
+	}
+	return rep, nil
+}
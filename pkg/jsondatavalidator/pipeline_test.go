@@ -0,0 +1,80 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+var testDeviceSchema = []byte(`{"type": "object", "required": ["vm"], "properties": {"vm": {"additionalProperties": false, "type": "object", "required": ["vcpus", "name"], "properties": {"vcpus": {"minimum": 2, "type": "integer", "maximum": 16, "multipleOf": 2}, "name": {"pattern": "^[A-Za-z][-A-Za-z0-9_]*$", "type": "string"}, "memory": {"minimum": 512, "type": "integer", "maximum": 16384, "multipleOf": 512}}}}}`)
+
+func TestValidateAndRenderParameterizedTemplate(t *testing.T) {
+	var testParameterizedData = []byte(`
+vm:
+  name: $name
+  vcpus: $vcpus
+  memory: $memory
+`)
+	var testParameterizedDataWithoutName = []byte(`
+vm:
+  vcpus: $vcpus
+`)
+	const vmID = "VM-0123abcd-0123-abcd-0123-0123456789ab"
+
+	testTable := []struct {
+		description      string
+		template         []byte
+		regExpStr        string
+		inputParams      []byte
+		expectedStage    jsondatavalidator.Stage
+		expectedErr      error
+		expectedRendered string
+	}{
+		{"Success", testParameterizedData, `\$(.*)`,
+			[]byte(`{"vm_id": "` + vmID + `", "name": "web", "vcpus": 4, "memory": 1024}`),
+			"", nil, `{"vm":{"memory":1024,"name":"web","vcpus":4}}`},
+		{"Invalid regexp", testParameterizedData, `\$(`,
+			[]byte(`{}`),
+			jsondatavalidator.StageGenerateSchema, nil, ""},
+		{"Invalid input parameters", testParameterizedData, `\$(.*)`,
+			[]byte(`{"vm_id": "` + vmID + `", "name": "web", "vcpus": 3, "memory": 1024}`),
+			jsondatavalidator.StageValidateInputParams, jsondatavalidator.ErrValidation, ""},
+		{"Malformed input parameters", testParameterizedData, `\$(.*)`,
+			[]byte(`{"vm_id":`),
+			jsondatavalidator.StageValidateInputParams, jsondatavalidator.ErrUnmarshal, ""},
+		{"Invalid rendered document", testParameterizedDataWithoutName, `\$(.*)`,
+			[]byte(`{"vm_id": "` + vmID + `", "name": "web", "vcpus": 4}`),
+			jsondatavalidator.StageValidateRendered, jsondatavalidator.ErrValidation, `{"vm":{"vcpus":4}}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			rep, err := jsondatavalidator.ValidateAndRenderParameterizedTemplate(&jsondatavalidator.RenderRequest{
+				ParameterizedJSON:          tdr.template,
+				NonParamDefineJSONBuf:      testJSONNonParamSchema,
+				InputParamSchemaJSONBuf:    testInputParamJSONSchema,
+				KeysToAddToRequiredSection: []string{"vm_id"},
+				RegExpStr:                  tdr.regExpStr,
+				InputParams:                tdr.inputParams,
+				DeviceSchemaJSONBuf:        testDeviceSchema,
+			})
+			t.Log(err)
+			if rep.FailedStage != tdr.expectedStage || rep.Succeeded() != (tdr.expectedStage == "") {
+				t.Errorf("expected stage %q to fail, got %q", tdr.expectedStage, rep.FailedStage)
+			}
+			var serr *jsondatavalidator.StageError
+			if tdr.expectedStage != "" && (!errors.As(err, &serr) || serr.Stage != tdr.expectedStage) {
+				t.Errorf("expected a *StageError for %q, got %v", tdr.expectedStage, err)
+			}
+			if tdr.expectedErr != nil && !errors.Is(err, tdr.expectedErr) {
+				t.Errorf("expected %v, got %v", tdr.expectedErr, err)
+			}
+			if string(rep.Rendered) != tdr.expectedRendered {
+				t.Errorf("expected rendered document %s, got %s", tdr.expectedRendered, rep.Rendered)
+			}
+		})
+	}
+}
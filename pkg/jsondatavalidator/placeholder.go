@@ -0,0 +1,234 @@
+package jsondatavalidator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// PlaceholderKind tells where in a parameterized template a placeholder is
+type PlaceholderKind string
+
+const (
+	// PlaceholderValue is a placeholder in the value of a key
+	PlaceholderValue PlaceholderKind = "value"
+	// PlaceholderKey is a placeholder in a key, for e.g; "$diskName: {...}"
+	PlaceholderKey PlaceholderKind = "key"
+	// PlaceholderItem is a placeholder in an item of an array, for e.g; "- $disk"
+	PlaceholderItem PlaceholderKind = "item"
+)
+
+// Placeholder is a parameterized variable found in a parameterized template
+type Placeholder struct {
+	// Name is the name of the parameter, for e.g; "vcpus" for "$vcpus"
+	Name string `json:"name"`
+	// Raw is the text of the placeholder in the template, for e.g; "${vcpus}"
+	Raw string `json:"raw"`
+	// Kind tells whether the placeholder is in a value, a key or an array item
+	Kind PlaceholderKind `json:"kind"`
+	// Pointer is the json-pointer of the value that holds the placeholder,
+	// for e.g; "#/vm/vcpus". For a key, it is the pointer of the member
+	// whose key holds the placeholder, for e.g; "#/vm/disks/$diskName".
+	Pointer string `json:"pointer"`
+	// ParentKey is the key of the value that holds the placeholder, for
+	// e.g; "vcpus". For an array item, it is the key of the array, and for
+	// a key, the key of the object.
+	ParentKey string `json:"parentKey"`
+	// Source is the part of the template that holds the placeholder
+	Source SourceRange `json:"source"`
+	// Embedded is true if the placeholder is part of a longer string, for
+	// e.g; "$env" in "web-$env-$index"
+	Embedded bool `json:"embedded,omitempty"`
+	// Value is the string of the template that holds an embedded placeholder
+	Value string `json:"value,omitempty"`
+	// Type is the type the placeholder declares for the parameter, for
+	// e.g; "integer" for "${memory:integer=1024}"
+	Type string `json:"type,omitempty"`
+	// Default is the default value the placeholder declares for the
+	// parameter, for e.g; 1024 for "$memory|1024"
+	Default interface{} `json:"default,omitempty"`
+	// HasDefault is true if the placeholder declares a default value
+	HasDefault bool `json:"hasDefault,omitempty"`
+	// Optional is true if the placeholder marks the parameter as optional,
+	// for e.g; "$memory?". The member or array item that holds it is left
+	// out of the rendered document when the parameter has no value.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// DiscoverPlaceholders takes as arguments:
+// i) a parameterized template, in json or yaml
+// ii) regExpStr: the regexp that matches a placeholder, whose last capture
+// group, if any, is the name of the parameter. The name may be followed by
+// a default value and a type, for e.g; "memory|1024" or
+// "memory:integer=1024", or marked as optional, for e.g; "memory?", if the
+// regexp captures them with the name.
+// The function walks the parsed template and returns the placeholders found
+// in its keys and values, in the order they appear in the template. A string value
+// may hold more than one placeholder, for e.g; "web-$env-$index".
+func DiscoverPlaceholders(parameterizedJSON []byte, regExpStr string) ([]Placeholder, error) {
+	syntax, err := NewRegexpSyntax(regExpStr)
+	if err != nil {
+		return nil, err
+	}
+	return discoverPlaceholders(parameterizedJSON, syntax)
+}
+
+// DiscoverPlaceholdersWithSyntax is DiscoverPlaceholders for a template
+// whose placeholders are written in the syntax, for e.g; the one returned
+// by LookupPlaceholderSyntax(SyntaxGoTemplate)
+func DiscoverPlaceholdersWithSyntax(parameterizedJSON []byte, syntax PlaceholderSyntax) ([]Placeholder, error) {
+	return discoverPlaceholders(parameterizedJSON, syntax)
+}
+
+// discoverPlaceholders is DiscoverPlaceholders with a placeholder syntax
+func discoverPlaceholders(parameterizedJSON []byte, syntax PlaceholderSyntax) ([]Placeholder, error) {
+	tmpl, err := parseTemplate(parameterizedJSON, syntax)
+	if err != nil {
+		return nil, err
+	}
+	phs := make([]Placeholder, 0)
+	if tmpl.root != nil {
+		tmpl.walk(tmpl.root, "#", "", PlaceholderValue, syntax, &phs)
+	}
+	log.WithFields(log.Fields{"Placeholders": phs}).Debug()
+	return phs, nil
+}
+
+// parsedTemplate is a parameterized template parsed into a tree of nodes
+type parsedTemplate struct {
+	root  *yamlv3.Node
+	lines [][]rune
+	// masked maps the tokens that stand in for placeholders, that are not
+	// valid yaml by themselves, to the text of the placeholders
+	masked map[string]string
+}
+
+// maskedToken is the format of the tokens that stand in for placeholders
+const maskedToken = "jsondatavalidator_placeholder_%d_"
+
+// parseTemplate parses a parameterized template. Some placeholder styles,
+// for e.g; "{vcpus" or ">>vcpus<<", make the template invalid yaml; if the
+// template can not be parsed, every placeholder is replaced by a plain
+// token and parsing is attempted again.
+func parseTemplate(buf []byte, syntax PlaceholderSyntax) (*parsedTemplate, error) {
+	root, err := parseYAMLNode(buf)
+	if err == nil {
+		return &parsedTemplate{root: root, lines: splitLines(buf)}, nil
+	}
+	log.WithFields(log.Fields{"TemplateParseError": err}).Debug()
+
+	masked := make(map[string]string)
+	var maskedBuf []byte
+	last := 0
+	for i, m := range syntax.FindAll(string(buf)) {
+		token := fmt.Sprintf(maskedToken, i)
+		masked[token] = string(buf[m.Start:m.End])
+		maskedBuf = append(append(maskedBuf, buf[last:m.Start]...), token...)
+		last = m.End
+	}
+	maskedBuf = append(maskedBuf, buf[last:]...)
+	if len(masked) == 0 {
+		return nil, err
+	}
+	root, merr := parseYAMLNode(maskedBuf)
+	if merr != nil {
+		// report the error of the template as written
+		return nil, err
+	}
+	return &parsedTemplate{root: root, lines: splitLines(maskedBuf), masked: masked}, nil
+}
+
+// value returns the text of a scalar node as written in the template
+func (tmpl *parsedTemplate) value(n *yamlv3.Node) string {
+	v := n.Value
+	for token, raw := range tmpl.masked {
+		v = strings.Replace(v, token, raw, -1)
+	}
+	return v
+}
+
+// source returns the part of the template that holds a scalar node
+func (tmpl *parsedTemplate) source(n *yamlv3.Node) SourceRange {
+	r := SourceRange{Start: Position{Line: n.Line, Column: n.Column}}
+	if len(tmpl.masked) > 0 && n.Style == 0 && !strings.Contains(n.Value, "\n") {
+		// the columns of the masked template past the start of the node
+		// do not match those of the template
+		r.End = Position{Line: n.Line, Column: n.Column + utf8.RuneCountInString(tmpl.value(n))}
+		return r
+	}
+	r.End = scalarEnd(n, tmpl.lines)
+	return r
+}
+
+// walk appends the placeholders found in the keys and values of the node
+// and of its descendants to phs. kind is the kind of a placeholder found in
+// the node itself.
+func (tmpl *parsedTemplate) walk(n *yamlv3.Node, ptr string, parentKey string,
+	kind PlaceholderKind, syntax PlaceholderSyntax, phs *[]Placeholder) {
+	n = resolveAlias(n)
+	switch n.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := tmpl.value(n.Content[i])
+			keyPtr := ptr + "/" + escapePtrToken(key)
+			if n.Content[i].Tag == "!!str" {
+				tmpl.match(n.Content[i], keyPtr, parentKey, PlaceholderKey, syntax, phs)
+			}
+			tmpl.walk(n.Content[i+1], keyPtr, key, PlaceholderValue, syntax, phs)
+		}
+	case yamlv3.SequenceNode:
+		for i, c := range n.Content {
+			tmpl.walk(c, ptr+"/"+strconv.Itoa(i), parentKey, PlaceholderItem, syntax, phs)
+		}
+	case yamlv3.ScalarNode:
+		if n.Tag != "!!str" {
+			return
+		}
+		tmpl.match(n, ptr, parentKey, kind, syntax, phs)
+	}
+}
+
+// match appends the placeholders found in a string node to phs
+func (tmpl *parsedTemplate) match(n *yamlv3.Node, ptr string, parentKey string,
+	kind PlaceholderKind, syntax PlaceholderSyntax, phs *[]Placeholder) {
+	v := tmpl.value(n)
+	matches := syntax.FindAll(v)
+	embedded := len(matches) > 1 || (len(matches) == 1 && (matches[0].Start != 0 || matches[0].End != len(v)))
+	for _, m := range matches {
+		spec := parseParameter(m.Text)
+		ph := Placeholder{
+			Name:       spec.name,
+			Raw:        v[m.Start:m.End],
+			Kind:       kind,
+			Pointer:    ptr,
+			ParentKey:  parentKey,
+			Source:     tmpl.source(n),
+			Embedded:   embedded,
+			Type:       spec.typ,
+			Default:    spec.def,
+			HasDefault: spec.hasDefault,
+			Optional:   spec.optional,
+		}
+		if embedded {
+			ph.Value = v
+		}
+		*phs = append(*phs, ph)
+	}
+}
+
+// placeholderName returns the name of the parameter from the result of
+// FindStringSubmatch, for e.g; "[>>memory memory]", i.e; the last element
+// of the result. Capture groups that did not participate in the match, as
+// happens with alternations, are skipped.
+func placeholderName(res []string) string {
+	for i := len(res) - 1; i > 0; i-- {
+		if res[i] != "" {
+			return res[i]
+		}
+	}
+	return res[len(res)-1]
+}
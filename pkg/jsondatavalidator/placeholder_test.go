@@ -0,0 +1,128 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestDiscoverPlaceholders(t *testing.T) {
+	type ph struct {
+		name, raw, pointer, parentKey string
+		line, column                  int
+	}
+	testTable := []struct {
+		description    string
+		testData       []byte
+		regExpStr      string
+		expectedOutput []ph
+	}{
+		{"Minified JSON", []byte(`{"vm":{"vcpus":"$vcpus","memory":"$memory"}}`), `\$([A-Za-z][-A-Za-z0-9_]*)`, []ph{
+			{"vcpus", "$vcpus", "#/vm/vcpus", "vcpus", 1, 16},
+			{"memory", "$memory", "#/vm/memory", "memory", 1, 34},
+		}},
+		{"Several placeholders per line", []byte("vm: {vcpus: $vcpus, memory: $memory}\n"), `\$([A-Za-z][-A-Za-z0-9_]*)`, []ph{
+			{"vcpus", "$vcpus", "#/vm/vcpus", "vcpus", 1, 13},
+			{"memory", "$memory", "#/vm/memory", "memory", 1, 29},
+		}},
+		{"Values containing colons", []byte("vm:\n  url: \"http://host:8080/$path\"\n  image: \"a:b\"\n"), `\$([A-Za-z][-A-Za-z0-9_]*)`, []ph{
+			{"path", "$path", "#/vm/url", "url", 2, 8},
+		}},
+		{"Nested structures and arrays", []byte("vm:\n  disks:\n    - size: $size\n    - $disk\n  nics: [$nic]\n"), `\$([A-Za-z][-A-Za-z0-9_]*)`, []ph{
+			{"size", "$size", "#/vm/disks/0/size", "size", 3, 13},
+			{"disk", "$disk", "#/vm/disks/1", "disks", 4, 7},
+			{"nic", "$nic", "#/vm/nics/0", "nics", 5, 10},
+		}},
+		{"Placeholder that is not valid yaml", []byte("vm:\n  vcpus: >>vcpus<<\n  memory: {memory\n"), `>{2}([a-z]+)<{2}|\{([a-z]+)`, []ph{
+			{"vcpus", ">>vcpus<<", "#/vm/vcpus", "vcpus", 2, 10},
+			{"memory", "{memory", "#/vm/memory", "memory", 3, 11},
+		}},
+		{"Placeholders embedded in a string", []byte("vm:\n  name: web-$env-${index}\n"), `\$\{?([A-Za-z][A-Za-z0-9_]*)\}?`, []ph{
+			{"env", "$env", "#/vm/name", "name", 2, 9},
+			{"index", "${index}", "#/vm/name", "name", 2, 9},
+		}},
+		{"Non parameterized template", []byte("vm:\n  vcpus: 4\n"), `\$([A-Za-z][-A-Za-z0-9_]*)`, []ph{}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			phs, err := jsondatavalidator.DiscoverPlaceholders(tdr.testData, tdr.regExpStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(phs)
+			out := make([]ph, 0)
+			for _, p := range phs {
+				out = append(out, ph{p.Name, p.Raw, p.Pointer, p.ParentKey, p.Source.Start.Line, p.Source.Start.Column})
+			}
+			if !reflect.DeepEqual(tdr.expectedOutput, out) {
+				t.Errorf("expected %v", tdr.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestDiscoverPlaceholderKinds(t *testing.T) {
+	phs, err := jsondatavalidator.DiscoverPlaceholders([]byte("vm:\n  disks:\n    $diskName:\n      size: $size\n  nics:\n    - $nic\n"),
+		`\$([A-Za-z][-A-Za-z0-9_]*)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(phs)
+	expectedOutput := []string{
+		"diskName key #/vm/disks/$diskName disks",
+		"size value #/vm/disks/$diskName/size size",
+		"nic item #/vm/nics/0 nics",
+	}
+	out := make([]string, 0)
+	for _, p := range phs {
+		out = append(out, fmt.Sprintf("%s %s %s %s", p.Name, p.Kind, p.Pointer, p.ParentKey))
+	}
+	if !reflect.DeepEqual(expectedOutput, out) {
+		t.Errorf("expected %v, got %v", expectedOutput, out)
+	}
+}
+
+func TestDiscoverPlaceholdersErrors(t *testing.T) {
+	testTable := []struct {
+		description string
+		testData    []byte
+		regExpStr   string
+	}{
+		{"Invalid regexp", []byte("vm: $vm"), `\$(`},
+		{"Invalid template", []byte("vm: [1, 2"), `\$(.*)`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			if _, err := jsondatavalidator.DiscoverPlaceholders(tdr.testData, tdr.regExpStr); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDiscoverPlaceholderDeclarations(t *testing.T) {
+	phs, err := jsondatavalidator.DiscoverPlaceholders(
+		[]byte("vm:\n  memory: $memory|1024\n  vcpus: ${vcpus:integer=2}\n  name: ${name:string=007}\n  image: ${image:string}\n"),
+		`\$\{([^}]*)\}|\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(phs)
+	expectedOutput := []string{
+		"memory  1024 true",
+		"vcpus integer 2 true",
+		"name string 007 true",
+		"image string <nil> false",
+	}
+	out := make([]string, 0)
+	for _, p := range phs {
+		out = append(out, fmt.Sprintf("%s %s %v %v", p.Name, p.Type, p.Default, p.HasDefault))
+	}
+	if !reflect.DeepEqual(expectedOutput, out) {
+		t.Errorf("expected %v, got %v", expectedOutput, out)
+	}
+}
@@ -0,0 +1,175 @@
+package jsondatavalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Profile is a named overlay of a base schema, for e.g; a "dev" profile
+// that caps the vcpus of a vm at 4 where the base schema allows 16
+type Profile struct {
+	Name string
+	// MergePatch, an RFC 7396 JSON Merge Patch, and then JSONPatch, an RFC
+	// 6902 JSON Patch, are applied to the base schema. Either may be empty.
+	MergePatch []byte
+	JSONPatch  []byte
+	// ForbidLoosening holds the json-pointers of the base schema below
+	// which the profile may only make changes that tighten constraints,
+	// and not ones DiffSchemas can not classify with certainty, such as a
+	// changed "pattern", for e.g;
+	// "#/vmDeviceDefine/vm/properties/vcpus", or "#" for the whole schema
+	ForbidLoosening []string
+}
+
+// NewProfile takes as arguments:
+// i) the name of the profile
+// ii) overlayJSON: the overlay, in json or yaml, for e.g; the content of a
+// "dev.yaml" file. An object is a JSON Merge Patch, an array a JSON Patch.
+// The function returns the profile, that does not forbid loosening.
+func NewProfile(name string, overlayJSON []byte) (Profile, error) {
+	log.Debug()
+	overlay, err := decodeJSONBuf(overlayJSON)
+	if err != nil {
+		return Profile{}, err
+	}
+	b, err := json.Marshal(overlay)
+	if err != nil {
+		return Profile{}, err
+	}
+	p := Profile{Name: name}
+	switch overlay.(type) {
+	case map[string]interface{}:
+		p.MergePatch = b
+	case []interface{}:
+		p.JSONPatch = b
+	default:
+		return Profile{}, &UnmarshalError{Err: fmt.Errorf("the overlay of profile %q is neither an object nor an array", name)}
+	}
+	return p, nil
+}
+
+// CheckProfile takes as arguments:
+// i) baseSchemaJSON: the base schema, for e.g; a non parameterized schema
+// ii) the profile to apply to it
+// The function returns the profile schema and its differences with the
+// base schema, as DiffSchemas does. If the profile loosens a constraint
+// below one of its ForbidLoosening pointers, changes its "type", or makes
+// a change that is not known to tighten it, for e.g; of a "not" or a
+// "$ref", a *ProfileError is returned.
+func CheckProfile(baseSchemaJSON []byte, profile Profile) ([]byte, *SchemaDiff, error) {
+	log.Debug()
+	schema := baseSchemaJSON
+	var err error
+	if len(profile.MergePatch) > 0 {
+		if schema, err = MergePatch(schema, profile.MergePatch); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(profile.JSONPatch) > 0 {
+		if schema, err = ApplyJSONPatch(schema, profile.JSONPatch); err != nil {
+			return nil, nil, err
+		}
+	}
+	diff, err := DiffSchemas(baseSchemaJSON, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	var refused []SchemaChange
+	for _, c := range diff.Changes {
+		mayLoosen := c.Class == ChangeLoosened || c.Class == ChangeTypeChanged || c.Uncertain
+		if mayLoosen && underAnyPointer(c.Pointer, profile.ForbidLoosening) {
+			refused = append(refused, c)
+		}
+	}
+	if len(refused) > 0 {
+		log.WithFields(log.Fields{"Profile": profile.Name, "Changes": refused}).Error()
+		return nil, diff, &ProfileError{Profile: profile.Name, Changes: refused}
+	}
+	return schema, diff, nil
+}
+
+// underAnyPointer returns true if the json-pointer is one of the pointers
+// or below one of them
+func underAnyPointer(ptr string, pointers []string) bool {
+	for _, p := range pointers {
+		if ptr == p || strings.HasPrefix(ptr, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileSet is a base schema and the profiles that overlay it
+type ProfileSet struct {
+	base     []byte
+	profiles map[string]Profile
+	schemas  map[string][]byte
+}
+
+// NewProfileSet takes as arguments:
+// i) baseSchemaJSON: the base schema
+// ii) the profiles of the base schema, with distinct names
+// The function checks, with CheckProfile, that every profile applies to
+// the base schema, and returns the set. The schema of a profile is then
+// chosen by its name, at generation time with Schema or at validation
+// time with NewValidator.
+func NewProfileSet(baseSchemaJSON []byte, profiles ...Profile) (*ProfileSet, error) {
+	log.Debug()
+	var base interface{}
+	if err := json.Unmarshal(baseSchemaJSON, &base); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	ps := &ProfileSet{base: baseSchemaJSON, profiles: make(map[string]Profile, len(profiles)),
+		schemas: make(map[string][]byte, len(profiles))}
+	for _, p := range profiles {
+		if _, ok := ps.profiles[p.Name]; ok || p.Name == "" {
+			return nil, fmt.Errorf("profile name %q is empty or not unique", p.Name)
+		}
+		schema, _, err := CheckProfile(baseSchemaJSON, p)
+		if err != nil {
+			return nil, err
+		}
+		ps.profiles[p.Name] = p
+		ps.schemas[p.Name] = schema
+	}
+	return ps, nil
+}
+
+// Names returns the names of the profiles of the set, in sorted order
+func (ps *ProfileSet) Names() []string {
+	names := make([]string, 0, len(ps.profiles))
+	for name := range ps.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Schema returns the schema of a profile, for e.g; to pass as the
+// nonParamDefineJSONBuf of GenerateJSONSchemaFromParameterizedTemplate.
+// The empty name is the base schema.
+func (ps *ProfileSet) Schema(name string) ([]byte, error) {
+	if name == "" {
+		return ps.base, nil
+	}
+	schema, ok := ps.schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProfile, name)
+	}
+	return schema, nil
+}
+
+// NewValidator returns a Validator for the schema of a profile, loaded as
+// url, as NewValidator does
+func (ps *ProfileSet) NewValidator(name string, url string, opts ...ValidatorOption) (*Validator, error) {
+	schema, err := ps.Schema(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewValidator(bytes.NewReader(schema), url, opts...)
+}
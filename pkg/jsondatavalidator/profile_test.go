@@ -0,0 +1,195 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+var testProfileBaseSchema = []byte(`{"type": "object", "properties": {"vm": {"$ref": "#/definitions/vm"}},
+	"definitions": {"vm": {"type": "object", "properties": {
+	"vcpus": {"type": "integer", "minimum": 2, "maximum": 16},
+	"memory": {"type": "integer", "minimum": 512, "maximum": 16384},
+	"name": {"type": "string", "pattern": "^vm-"}}}}}`)
+
+func TestCheckProfile(t *testing.T) {
+	testTable := []struct {
+		description     string
+		overlay         string
+		forbidLoosening []string
+		expectedChanges []string
+		expectedErr     error
+	}{
+		{"Dev caps vcpus", "definitions:\n  vm:\n    properties:\n      vcpus:\n        maximum: 4\n", nil,
+			[]string{"#/definitions/vm/properties/vcpus/maximum: tightened: changed from 16 to 4"}, nil},
+		{"JSON patch overlay", `[{"op": "replace", "path": "/definitions/vm/properties/memory/minimum", "value": 256}]`, nil,
+			[]string{"#/definitions/vm/properties/memory/minimum: loosened: changed from 512 to 256"}, nil},
+		{"Loosening allowed elsewhere", `{"definitions": {"vm": {"properties": {"memory": {"maximum": 32768}}}}}`,
+			[]string{"#/definitions/vm/properties/vcpus"},
+			[]string{"#/definitions/vm/properties/memory/maximum: loosened: changed from 16384 to 32768"}, nil},
+		{"Loosening forbidden", `{"definitions": {"vm": {"properties": {"vcpus": {"maximum": 32}}}}}`,
+			[]string{"#/definitions/vm/properties/vcpus"},
+			[]string{"#/definitions/vm/properties/vcpus/maximum: loosened: changed from 16 to 32"},
+			jsondatavalidator.ErrLoosenedProfile},
+		{"Loosening forbidden everywhere", `{"definitions": {"vm": {"properties": {"memory": {"maximum": null}}}}}`,
+			[]string{"#"},
+			[]string{"#/definitions/vm/properties/memory/maximum: loosened: removed, was 16384"},
+			jsondatavalidator.ErrLoosenedProfile},
+		{"Tightening allowed", `{"definitions": {"vm": {"properties": {"vcpus": {"maximum": 4, "enum": [2, 4]}}}}}`,
+			[]string{"#"},
+			[]string{"#/definitions/vm/properties/vcpus/enum: tightened: added with [2,4]",
+				"#/definitions/vm/properties/vcpus/maximum: tightened: changed from 16 to 4"}, nil},
+		{"Changed pattern forbidden", `{"definitions": {"vm": {"properties": {"name": {"pattern": ".*"}}}}}`,
+			[]string{"#/definitions/vm"},
+			[]string{`#/definitions/vm/properties/name/pattern: tightened: changed from "^vm-" to ".*"`},
+			jsondatavalidator.ErrLoosenedProfile},
+		{"Changed type forbidden", `{"definitions": {"vm": {"properties": {"vcpus": {"type": "string"}}}}}`,
+			[]string{"#/definitions/vm"},
+			[]string{`#/definitions/vm/properties/vcpus/type: typeChanged: changed from "integer" to "string"`},
+			jsondatavalidator.ErrLoosenedProfile},
+		{"Unknown keyword forbidden", `{"definitions": {"vm": {"properties": {"vcpus": {"not": {"const": 3}}}}}}`,
+			[]string{"#/definitions/vm"},
+			[]string{`#/definitions/vm/properties/vcpus/not: tightened: added with {"const":3}`},
+			jsondatavalidator.ErrLoosenedProfile},
+		{"Changed reference forbidden", `{"properties": {"vm": {"$ref": "#/definitions/vm/properties/name"}}}`,
+			[]string{"#"},
+			[]string{`#/properties/vm/$ref: tightened: changed from "#/definitions/vm" to "#/definitions/vm/properties/name"`},
+			jsondatavalidator.ErrLoosenedProfile},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			p, err := jsondatavalidator.NewProfile("dev", []byte(tdr.overlay))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.ForbidLoosening = tdr.forbidLoosening
+			_, diff, err := jsondatavalidator.CheckProfile(testProfileBaseSchema, p)
+			if !errors.Is(err, tdr.expectedErr) {
+				t.Fatalf("expected %v, got %v", tdr.expectedErr, err)
+			}
+			if err != nil && !errors.Is(err, jsondatavalidator.ErrInvalidSchema) {
+				t.Errorf("expected %v to match ErrInvalidSchema", err)
+			}
+			var changes []string
+			for _, c := range diff.Changes {
+				changes = append(changes, c.String())
+			}
+			if !reflect.DeepEqual(tdr.expectedChanges, changes) {
+				t.Errorf("expected %v, got %v", tdr.expectedChanges, changes)
+			}
+		})
+	}
+}
+
+func TestProfileSet(t *testing.T) {
+	dev, err := jsondatavalidator.NewProfile("dev", []byte(`{"definitions": {"vm": {"properties": {"vcpus": {"maximum": 4}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.ForbidLoosening = []string{"#"}
+	prod := jsondatavalidator.Profile{Name: "prod"}
+	ps, err := jsondatavalidator.NewProfileSet(testProfileBaseSchema, dev, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := ps.Names(); !reflect.DeepEqual(names, []string{"dev", "prod"}) {
+		t.Errorf("expected [dev prod], got %v", names)
+	}
+
+	var regExpStr = `\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`
+	var testTemplate = []byte("vm:\n  vcpus: $vcpus\n")
+	testTable := []struct {
+		description    string
+		profile        string
+		vcpus          int
+		expectedErr    error
+		expectedOutput string
+	}{
+		{"Base schema", "", 8, nil,
+			`{"properties":{"vcpus":{"maximum":16,"minimum":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`},
+		{"Prod allows 16 vcpus", "prod", 16, nil,
+			`{"properties":{"vcpus":{"maximum":16,"minimum":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`},
+		{"Dev caps vcpus at 4", "dev", 8, jsondatavalidator.ErrInvalidInput,
+			`{"properties":{"vcpus":{"maximum":4,"minimum":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`},
+		{"Unknown profile", "staging", 8, jsondatavalidator.ErrUnknownProfile, ""},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			schema, err := ps.Schema(tdr.profile)
+			if tdr.expectedOutput == "" {
+				if !errors.Is(err, tdr.expectedErr) {
+					t.Errorf("expected %v, got %v", tdr.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(testTemplate, schema,
+				[]byte(`{"inputParam": {"type": "object"}}`), nil, regExpStr, jsondatavalidator.WithCanonicalJSON())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s, got %s", tdr.expectedOutput, out)
+			}
+			v, err := ps.NewValidator(tdr.profile, "vm.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = v.Validate([]byte(fmt.Sprintf(`{"vm": {"vcpus": %d}}`, tdr.vcpus)))
+			if !errors.Is(err, tdr.expectedErr) {
+				t.Errorf("expected %v, got %v", tdr.expectedErr, err)
+			}
+		})
+	}
+
+	loose := jsondatavalidator.Profile{Name: "lab", MergePatch: []byte(`{"definitions": {"vm": {"properties": {"vcpus": {"maximum": 64}}}}}`),
+		ForbidLoosening: []string{"#/definitions/vm/properties/vcpus"}}
+	if _, err := jsondatavalidator.NewProfileSet(testProfileBaseSchema, loose); !errors.Is(err, jsondatavalidator.ErrLoosenedProfile) {
+		t.Errorf("expected a loosened profile error, got %v", err)
+	}
+	if _, err := jsondatavalidator.NewProfileSet(testProfileBaseSchema, prod, prod); err == nil {
+		t.Error("expected an error for duplicate profile names")
+	}
+}
+
+func TestGenerateJSONSchemaWithProfile(t *testing.T) {
+	var regExpStr = `\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`
+	var testTemplate = []byte("vm:\n  vcpus: $vcpus\n")
+	testTable := []struct {
+		description    string
+		profile        jsondatavalidator.Profile
+		expectedErr    error
+		expectedOutput string
+	}{
+		{"Tightened", jsondatavalidator.Profile{Name: "dev",
+			MergePatch:      []byte(`{"definitions": {"vm": {"properties": {"vcpus": {"maximum": 4}}}}}`),
+			ForbidLoosening: []string{"#"}}, nil,
+			`{"properties":{"vcpus":{"maximum":4,"minimum":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`},
+		{"Loosened", jsondatavalidator.Profile{Name: "lab",
+			JSONPatch: []byte(`[{"op": "replace", "path": "/definitions/vm/properties/vcpus/maximum", "value": 64}]`)}, nil,
+			`{"properties":{"vcpus":{"maximum":64,"minimum":2,"type":"integer"}},"required":["vcpus"],"type":"object"}`},
+		{"Loosening forbidden", jsondatavalidator.Profile{Name: "lab",
+			JSONPatch:       []byte(`[{"op": "replace", "path": "/definitions/vm/properties/vcpus/maximum", "value": 64}]`),
+			ForbidLoosening: []string{"#"}}, jsondatavalidator.ErrLoosenedProfile, ""},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			out, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(testTemplate, testProfileBaseSchema,
+				[]byte(`{"inputParam": {"type": "object"}}`), nil, regExpStr,
+				jsondatavalidator.WithCanonicalJSON(), jsondatavalidator.WithProfile(tdr.profile))
+			if !errors.Is(err, tdr.expectedErr) {
+				t.Fatalf("expected %v, got %v", tdr.expectedErr, err)
+			}
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s, got %s", tdr.expectedOutput, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package jsondatavalidator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// RenderParameterizedTemplate takes as arguments:
+// i) a parameterized template, in json or yaml
+// ii) regExpStr: the regexp that matches a placeholder, the same as the one
+// given to GenerateJSONSchemaFromParameterizedTemplate
+// iii) inputParams: the value of each parameter, keyed by parameter name
+// The function substitutes every placeholder of the template with the value
+// of its parameter and returns the rendered document as json. A value that
+// is the placeholder alone is replaced keeping the type of the parameter
+// value, for e.g; "$vcpus" becomes the integer 4 and not the string "4".
+// A placeholder in a key is replaced by the value formatted as text. A
+// parameter with no value is replaced by the default value its placeholder
+// declares, if any; if its placeholder marks it as optional, the member or
+// array item that holds the placeholder is left out.
+func RenderParameterizedTemplate(parameterizedJSON []byte, regExpStr string,
+	inputParams map[string]interface{}) ([]byte, error) {
+	log.Debug()
+	syntax, err := NewRegexpSyntax(regExpStr)
+	if err != nil {
+		return nil, err
+	}
+	return RenderParameterizedTemplateWithSyntax(parameterizedJSON, syntax, inputParams)
+}
+
+// RenderParameterizedTemplateWithSyntax is RenderParameterizedTemplate for
+// a template whose placeholders are written in the syntax, for e.g; the one
+// returned by LookupPlaceholderSyntax(SyntaxDollarBrace). Escaped delimiters,
+// for e.g; "$$", are rendered as the literal delimiters.
+func RenderParameterizedTemplateWithSyntax(parameterizedJSON []byte, syntax PlaceholderSyntax,
+	inputParams map[string]interface{}) ([]byte, error) {
+	log.Debug()
+	doc, err := renderParameterizedTemplate(parameterizedJSON, syntax, inputParams)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// renderParameterizedTemplate renders the template into the generic form
+// of a decoded json document
+func renderParameterizedTemplate(parameterizedJSON []byte, syntax PlaceholderSyntax,
+	inputParams map[string]interface{}) (interface{}, error) {
+	tmpl, err := parseTemplate(parameterizedJSON, syntax)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.root == nil {
+		return nil, nil
+	}
+	r := &renderer{tmpl: tmpl, syntax: syntax, values: inputParams}
+	doc, err := r.render(tmpl.root, "#")
+	if err == errOmitted {
+		return nil, nil
+	}
+	return doc, err
+}
+
+// renderer holds what is needed to render the nodes of a template
+type renderer struct {
+	tmpl   *parsedTemplate
+	syntax PlaceholderSyntax
+	values map[string]interface{}
+}
+
+// render returns the rendered value of the node
+func (r *renderer) render(n *yamlv3.Node, ptr string) (interface{}, error) {
+	n = resolveAlias(n)
+	switch n.Kind {
+	case yamlv3.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		// params holds the parameter each rendered key comes from
+		params := make(map[string]string)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := r.tmpl.value(n.Content[i])
+			keyPtr := ptr + "/" + escapePtrToken(key)
+			param := ""
+			if n.Content[i].Tag == "!!str" {
+				k, err := r.renderString(key, keyPtr)
+				if err == errOmitted {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				key = formatParameterValue(k)
+				if matches := r.syntax.FindAll(r.tmpl.value(n.Content[i])); matches != nil {
+					param = parseParameter(matches[0].Text).name
+				}
+			}
+			if _, ok := m[key]; ok && (param != "" || params[key] != "") {
+				if param == "" {
+					param = params[key]
+				}
+				return nil, &ParameterError{Name: param, Pointer: keyPtr, Err: ErrDuplicateKey}
+			}
+			if param != "" {
+				params[key] = param
+			}
+			v, err := r.render(n.Content[i+1], keyPtr)
+			if err == errOmitted {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		}
+		return m, nil
+	case yamlv3.SequenceNode:
+		a := make([]interface{}, 0, len(n.Content))
+		for i, c := range n.Content {
+			v, err := r.render(c, ptr+"/"+strconv.Itoa(i))
+			if err == errOmitted {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case yamlv3.ScalarNode:
+		if n.Tag == "!!str" {
+			return r.renderString(r.tmpl.value(n), ptr)
+		}
+		return scalarValue(n)
+	}
+	return nil, nil
+}
+
+// errOmitted is returned by renderString when an optional parameter has no
+// value; the member or array item that holds it is left out
+var errOmitted = errors.New("omitted")
+
+// renderString substitutes the placeholders in a string value of the
+// template. A string that is the placeholder alone is replaced by the value
+// of the parameter; otherwise each placeholder is replaced by the value of
+// its parameter formatted as text, for e.g; "web-$env-$index" becomes
+// "web-prod-1". Escaped delimiters are replaced by the literal delimiters.
+func (r *renderer) renderString(s string, ptr string) (interface{}, error) {
+	matches := r.syntax.FindAll(s)
+	if matches == nil {
+		return r.syntax.Unescape(s), nil
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		spec := parseParameter(m.Text)
+		v, ok := r.values[spec.name]
+		if !ok && spec.hasDefault {
+			v, ok = spec.def, true
+		}
+		if !ok && spec.optional {
+			return nil, errOmitted
+		}
+		if !ok {
+			return nil, &ParameterError{Name: spec.name, Pointer: ptr, Err: ErrMissingParameter}
+		}
+		if len(matches) == 1 && m.Start == 0 && m.End == len(s) {
+			return v, nil
+		}
+		b.WriteString(r.syntax.Unescape(s[last:m.Start]))
+		b.WriteString(formatParameterValue(v))
+		last = m.End
+	}
+	b.WriteString(r.syntax.Unescape(s[last:]))
+	return b.String(), nil
+}
+
+// scalarValue decodes a scalar node that is not a string. Timestamps and
+// other values that have no json equivalent are kept as written.
+func scalarValue(n *yamlv3.Node) (interface{}, error) {
+	switch n.Tag {
+	case "!!null", "!!bool", "!!int", "!!float":
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return n.Value, nil
+}
+
+// formatParameterValue returns a parameter value as text, numbers without
+// an exponent and objects and arrays as json
+func formatParameterValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,102 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestRenderParameterizedTemplate(t *testing.T) {
+	var regExpStr = `\$([A-Za-z][-A-Za-z0-9_]*)`
+	var testParameterizedData = []byte(`
+vm:
+  name: $name
+  vcpus: $vcpus
+  memory: $memory
+  enabled: true
+  disks:
+    - $disk
+    - path: /dev/$disk
+`)
+	testInputParams := map[string]interface{}{
+		"name":   "web-01",
+		"vcpus":  4,
+		"memory": float64(1024),
+		"disk":   "sda",
+		"index":  0,
+	}
+
+	testTable := []struct {
+		description    string
+		template       []byte
+		regExpStr      string
+		inputParams    map[string]interface{}
+		expectedOutput string
+	}{
+		{"Types of values are kept", testParameterizedData, regExpStr, testInputParams,
+			`{"vm":{"disks":["sda",{"path":"/dev/sda"}],"enabled":true,"memory":1024,"name":"web-01","vcpus":4}}`},
+		{"JSON template", []byte(`{"vm": {"vcpus": "$vcpus", "tags": ["$name", null, 1.5]}}`), regExpStr, testInputParams,
+			`{"vm":{"tags":["web-01",null,1.5],"vcpus":4}}`},
+		{"Object and array values", []byte(`{"vm": "$vm", "disks": "$disks"}`), regExpStr,
+			map[string]interface{}{"vm": map[string]interface{}{"vcpus": 2}, "disks": []interface{}{"sda"}},
+			`{"disks":["sda"],"vm":{"vcpus":2}}`},
+		{"Placeholder that is not valid yaml", []byte("vm:\n  vcpus: >>vcpus<<\n"), `>{2}(.*)<{2}`, testInputParams,
+			`{"vm":{"vcpus":4}}`},
+		{"Placeholders embedded in strings", []byte(`{"name": "web-$env-$index", "path": "/dev/${disk}", "vcpus": "${vcpus}"}`),
+			`\$\{?([A-Za-z][A-Za-z0-9_]*)\}?`, map[string]interface{}{"env": "prod", "index": 1, "disk": "sda", "vcpus": 2},
+			`{"name":"web-prod-1","path":"/dev/sda","vcpus":2}`},
+		{"Parameterized keys and array items", []byte("disks:\n  $disk:\n    size: 10\n  cd-$index: {}\nnics: [$name]\n"),
+			regExpStr, testInputParams,
+			`{"disks":{"cd-0":{},"sda":{"size":10}},"nics":["web-01"]}`},
+		{"Default values", []byte("vm:\n  memory: $memory|2048\n  swap: ${swap:integer=512}\n  name: web-${env=dev}\n"),
+			`\$\{([^}]*)\}|\$([A-Za-z][-A-Za-z0-9_]*(?:\|\S+)?)`, testInputParams,
+			`{"vm":{"memory":1024,"name":"web-dev","swap":512}}`},
+		{"Optional parameters", []byte("vm:\n  name: $name\n  memory: $memory?\n  disks: [$disk?, sdb]\n  $label?: x\n  path: /dev/$disk?\n"),
+			`\$([A-Za-z][-A-Za-z0-9_]*\??)`, map[string]interface{}{"name": "web-01"},
+			`{"vm":{"disks":["sdb"],"name":"web-01"}}`},
+		{"Non parameterized template", []byte("vm:\n  vcpus: 4\n"), regExpStr, nil,
+			`{"vm":{"vcpus":4}}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			out, err := jsondatavalidator.RenderParameterizedTemplate(tdr.template, tdr.regExpStr, tdr.inputParams)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(string(out))
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s", tdr.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestRenderParameterizedTemplateErrors(t *testing.T) {
+	_, err := jsondatavalidator.RenderParameterizedTemplate([]byte("vm:\n  vcpus: $vcpus\n"), `\$(.*)`, map[string]interface{}{})
+	var perr *jsondatavalidator.ParameterError
+	if !errors.Is(err, jsondatavalidator.ErrMissingParameter) || !errors.Is(err, jsondatavalidator.ErrInvalidInput) ||
+		!errors.As(err, &perr) || perr.Name != "vcpus" || perr.Pointer != "#/vm/vcpus" {
+		t.Errorf("expected a missing parameter error for vcpus, got %v", err)
+	}
+
+	_, err = jsondatavalidator.RenderParameterizedTemplate([]byte("disks:\n  $a: 1\n  $b: 2\n"), `\$(.*)`,
+		map[string]interface{}{"a": "sda", "b": "sda"})
+	if !errors.Is(err, jsondatavalidator.ErrDuplicateKey) || !errors.As(err, &perr) || perr.Name != "b" {
+		t.Errorf("expected a duplicate key error for b, got %v", err)
+	}
+
+	_, err = jsondatavalidator.RenderParameterizedTemplate([]byte("servers:\n  $name: 1\n  web: 2\n"), `\$(.*)`,
+		map[string]interface{}{"name": "web"})
+	if !errors.Is(err, jsondatavalidator.ErrDuplicateKey) || !errors.As(err, &perr) || perr.Name != "name" ||
+		perr.Pointer != "#/servers/web" {
+		t.Errorf("expected a duplicate key error for name at #/servers/web, got %v", err)
+	}
+
+	if _, err := jsondatavalidator.RenderParameterizedTemplate([]byte("vm: $vm"), `\$(`, nil); err == nil {
+		t.Error("expected an error for an invalid regexp")
+	}
+}
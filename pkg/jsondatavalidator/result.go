@@ -0,0 +1,140 @@
+package jsondatavalidator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Violation describes a single failure found while validating a json
+// document against a schema
+type Violation struct {
+	// InstancePtr is the json-pointer of the offending fragment in the document
+	InstancePtr string `json:"instancePtr"`
+	// SchemaPtr is the json-pointer of the schema keyword that was not satisfied
+	SchemaPtr string `json:"schemaPtr"`
+	// Keyword is the name of the schema keyword that was not satisfied
+	Keyword string `json:"keyword"`
+	// Message describes the failure
+	Message string `json:"message"`
+	// Source is the part of the validated buffer that holds the offending
+	// fragment, nil if it could not be determined
+	Source *SourceRange `json:"source,omitempty"`
+}
+
+// String returns the violation in the "I[...] S[...] message" format
+func (v Violation) String() string {
+	return fmt.Sprintf("I[%s] S[%s] %s", v.InstancePtr, v.SchemaPtr, v.Message)
+}
+
+// LocatedString returns the violation prefixed with its position in the
+// validated buffer, for e.g; "line 3, column 9: I[#/vm/vcpus] S[...] ..."
+func (v Violation) LocatedString() string {
+	if v.Source == nil {
+		return v.String()
+	}
+	return v.Source.String() + ": " + v.String()
+}
+
+// ValidationResult holds every violation reported when validating a json
+// document against a schema. A result without violations means the
+// document is valid.
+type ValidationResult struct {
+	Violations []Violation `json:"violations"`
+}
+
+// Valid returns true if no violations were reported
+func (res *ValidationResult) Valid() bool {
+	return len(res.Violations) == 0
+}
+
+// locate sets the source range of every violation from the buffer that was
+// validated. Violations are left without a source range if the buffer can
+// not be parsed for positions.
+func (res *ValidationResult) locate(jsonval []byte) {
+	if res.Valid() {
+		return
+	}
+	sm, err := NewSourceMap(jsonval)
+	if err != nil {
+		return
+	}
+	for i := range res.Violations {
+		if r, ok := sm.Lookup(res.Violations[i].InstancePtr); ok {
+			r := r
+			res.Violations[i].Source = &r
+		}
+	}
+}
+
+// newValidationResult flattens the tree of nested causes of a
+// jsonschema.ValidationError into a list of violations. Only the leaves of
+// the tree are kept, as the intermediate nodes merely state that a sub
+// schema did not validate. The library does not report the causes in a
+// stable order, so the violations are sorted by instance and schema pointer.
+func newValidationResult(ve *jsonschema.ValidationError) *ValidationResult {
+	res := &ValidationResult{Violations: make([]Violation, 0)}
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			schemaPtr := e.AbsoluteKeywordLocation
+			if i := strings.IndexByte(schemaPtr, '#'); i >= 0 {
+				schemaPtr = schemaPtr[i:]
+			}
+			res.Violations = append(res.Violations, Violation{
+				InstancePtr: "#" + e.InstanceLocation,
+				SchemaPtr:   schemaPtr,
+				Keyword:     keywordFromSchemaPtr(schemaPtr),
+				Message:     e.Message,
+			})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+	sort.SliceStable(res.Violations, func(i, j int) bool {
+		a, b := res.Violations[i], res.Violations[j]
+		if a.InstancePtr != b.InstancePtr {
+			return a.InstancePtr < b.InstancePtr
+		}
+		return a.SchemaPtr < b.SchemaPtr
+	})
+	return res
+}
+
+// nameKeywords are the schema keywords whose value is keyed by property or
+// definition names
+var nameKeywords = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"definitions":       true,
+	"$defs":             true,
+	"dependencies":      true,
+	"dependentRequired": true,
+	"dependentSchemas":  true,
+}
+
+// keywordFromSchemaPtr returns the last keyword of a schema json-pointer,
+// skipping property names and array indexes, for e.g; "additionalProperties"
+// for "#/properties/vm/additionalProperties" and "dependentRequired" for
+// "#/dependentRequired/user/0"
+func keywordFromSchemaPtr(ptr string) string {
+	kw := ""
+	tokens := strings.Split(strings.TrimPrefix(ptr, "#"), "/")
+	for i, tok := range tokens {
+		if tok == "" || (i > 0 && nameKeywords[tokens[i-1]]) {
+			continue
+		}
+		if _, err := strconv.Atoi(tok); err == nil {
+			continue
+		}
+		kw = tok
+	}
+	kw = strings.Replace(kw, "~1", "/", -1)
+	return strings.Replace(kw, "~0", "~", -1)
+}
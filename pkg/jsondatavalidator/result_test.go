@@ -0,0 +1,56 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+var testVMSchema = `{"type": "object", "properties": {"vm": {"additionalProperties": false, "type": "object", "required": ["vcpus"], "properties": {"vcpus": {"oneOf": [{"pattern": "^\\$[A-Za-z][-A-Za-z0-9_]*$", "type": "string"}, {"minimum": 2, "type": "integer", "maximum": 16, "multipleOf": 2}]}, "memory": {"minimum": 512, "type": "integer", "maximum": 16384, "multipleOf": 512}}}}}`
+
+func TestValidateJSONBufAgainstSchemaWithResult(t *testing.T) {
+	testTable := []struct {
+		description        string
+		jsonval            []byte
+		expectedViolations []jsondatavalidator.Violation
+	}{
+		{"Valid JSON", []byte(`{"vm": {"vcpus": 4, "memory": 1024}}`), []jsondatavalidator.Violation{}},
+		{"Single violation", []byte(`{"vm": {"vcpus": 4, "memory": 1000}}`), []jsondatavalidator.Violation{
+			{InstancePtr: "#/vm/memory", SchemaPtr: "#/properties/vm/properties/memory/multipleOf", Keyword: "multipleOf", Message: "1000 not multipleOf 512"},
+		}},
+		{"Violations of several properties", []byte(`{"vm": {"memory": 100, "proc": 1}}`), []jsondatavalidator.Violation{
+			{InstancePtr: "#/vm", SchemaPtr: "#/properties/vm/additionalProperties", Keyword: "additionalProperties", Message: "additionalProperties 'proc' not allowed"},
+			{InstancePtr: "#/vm", SchemaPtr: "#/properties/vm/required", Keyword: "required", Message: "missing properties: 'vcpus'"},
+			{InstancePtr: "#/vm/memory", SchemaPtr: "#/properties/vm/properties/memory/minimum", Keyword: "minimum", Message: "must be >= 512 but found 100"},
+			{InstancePtr: "#/vm/memory", SchemaPtr: "#/properties/vm/properties/memory/multipleOf", Keyword: "multipleOf", Message: "100 not multipleOf 512"},
+		}},
+		{"Violation of every oneOf branch", []byte(`{"vm": {"vcpus": 3}}`), []jsondatavalidator.Violation{
+			{InstancePtr: "#/vm/vcpus", SchemaPtr: "#/properties/vm/properties/vcpus/oneOf/0/type", Keyword: "type", Message: "expected string, but got number"},
+			{InstancePtr: "#/vm/vcpus", SchemaPtr: "#/properties/vm/properties/vcpus/oneOf/1/multipleOf", Keyword: "multipleOf", Message: "3 not multipleOf 2"},
+		}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := jsondatavalidator.ValidateJSONBufAgainstSchemaWithResult(tdr.jsonval, strings.NewReader(testVMSchema), "sch.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log(res.Violations)
+			// source ranges are covered by TestValidateWithResultSourceRanges
+			for i := range res.Violations {
+				res.Violations[i].Source = nil
+			}
+			if res.Valid() != (len(tdr.expectedViolations) == 0) {
+				t.Errorf("Valid() returned %v", res.Valid())
+			}
+			if !reflect.DeepEqual(tdr.expectedViolations, res.Violations) {
+				t.Errorf("expected %v", tdr.expectedViolations)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package jsondatavalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SchemaChange is a difference between two versions of a schema
+type SchemaChange struct {
+	// Pointer is the json-pointer of the keyword that changed, or of the
+	// schema, for e.g; "#/vm/properties/vcpus/maximum"
+	Pointer string      `json:"pointer"`
+	Class   ChangeClass `json:"class"`
+	// Breaking is true if documents valid for the old version may be
+	// invalid for the new one
+	Breaking bool `json:"breaking"`
+	// Uncertain is true if the class is a guess, for e.g; for a changed
+	// "pattern", and the change may then also make documents valid that
+	// were invalid for the old version
+	Uncertain bool   `json:"uncertain,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (c SchemaChange) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Pointer, c.Class, c.Message)
+}
+
+// SchemaDiff is the outcome of DiffSchemas
+type SchemaDiff struct {
+	// Changes are the differences between the versions, in the order the
+	// schemas are walked
+	Changes []SchemaChange `json:"changes"`
+}
+
+// Breaking returns the changes that are breaking
+func (diff *SchemaDiff) Breaking() []SchemaChange {
+	var changes []SchemaChange
+	for _, c := range diff.Changes {
+		if c.Breaking {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// DiffSchemas takes as arguments:
+// i) oldSchema: the released version of a schema, or of a container of
+// schemas such as a non parameterized schema holding "vmDeviceDefine"
+// ii) newSchema: the version that is to replace it
+// The function walks the two versions together and returns their
+// differences, each classified as breaking, if it can make a document that
+// is valid for the old version invalid for the new one, or non breaking,
+// if it only makes more documents valid. The keywords understood are
+// "required", "enum" and "const", the numeric range, the lengths and
+// sizes, "pattern", "properties", "patternProperties",
+// "additionalProperties", the items, "allOf", "anyOf" and "oneOf". A
+// change of another keyword, or a changed "pattern", can not be told apart
+// and is reported as breaking and Uncertain. A branch added to a "oneOf" is
+// breaking, since a document may then match more than one branch.
+func DiffSchemas(oldSchema, newSchema []byte) (*SchemaDiff, error) {
+	log.Debug()
+	var oldDoc, newDoc interface{}
+	if err := json.Unmarshal(oldSchema, &oldDoc); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	if err := json.Unmarshal(newSchema, &newDoc); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	d := &schemaDiffer{changes: make([]SchemaChange, 0)}
+	o, oldIsMap := oldDoc.(map[string]interface{})
+	n, newIsMap := newDoc.(map[string]interface{})
+	if oldIsMap && newIsMap && !isSchema(o) && !isSchema(n) && len(o)+len(n) > 0 {
+		d.diffContainer(o, n, "#")
+	} else {
+		d.diff(oldDoc, newDoc, "#")
+	}
+	log.WithFields(log.Fields{"Changes": d.changes}).Debug()
+	return &SchemaDiff{Changes: d.changes}, nil
+}
+
+// schemaDiffer collects the differences between two versions of a schema
+type schemaDiffer struct {
+	changes []SchemaChange
+}
+
+// add appends a change of the schema or keyword at the pointer
+func (d *schemaDiffer) add(ptr string, class ChangeClass, msg string) {
+	d.changes = append(d.changes, SchemaChange{Pointer: ptr, Class: class, Breaking: class.Breaking(), Message: msg})
+}
+
+// guess appends a change whose class can not be proven
+func (d *schemaDiffer) guess(ptr string, class ChangeClass, msg string) {
+	d.add(ptr, class, msg)
+	d.changes[len(d.changes)-1].Uncertain = true
+}
+
+// diffContainer diffs the schemas held by two versions of a container
+func (d *schemaDiffer) diffContainer(o, n map[string]interface{}, ptr string) {
+	for _, k := range unionKeys(o, n) {
+		p := ptr + "/" + escapePtrToken(k)
+		ov, inOld := o[k].(map[string]interface{})
+		nv, inNew := n[k].(map[string]interface{})
+		switch {
+		case inOld && inNew && isDefinitionsKeyword(k):
+			d.diffMembers(ov, nv, p, "definition")
+		case inOld && inNew && !isSchema(ov) && !isSchema(nv):
+			d.diffContainer(ov, nv, p)
+		case inOld && inNew:
+			d.diff(ov, nv, p)
+		case inOld:
+			d.guess(p, ChangeTightened, "schema removed")
+		case inNew:
+			d.add(p, ChangeLoosened, "schema added")
+		}
+	}
+}
+
+// diff appends the differences between two versions of a schema
+func (d *schemaDiffer) diff(oldV, newV interface{}, ptr string) {
+	oldB, oldIsBool := oldV.(bool)
+	newB, newIsBool := newV.(bool)
+	switch {
+	case oldIsBool && newIsBool && oldB == newB:
+		return
+	case newIsBool && !newB:
+		d.add(ptr, ChangeTightened, "schema accepts no value")
+		return
+	case oldIsBool && !oldB:
+		d.add(ptr, ChangeLoosened, "schema accepted no value")
+		return
+	}
+	// the true schema is the empty schema
+	o, _ := oldV.(map[string]interface{})
+	n, _ := newV.(map[string]interface{})
+
+	for _, c := range compareConstraints(o, n) {
+		if c.uncertain {
+			d.guess(ptr+"/"+c.keyword, c.class, c.message)
+		} else {
+			d.add(ptr+"/"+c.keyword, c.class, c.message)
+		}
+	}
+	d.diffRequired(o, n, ptr)
+	d.diffProperties(o, n, ptr, "properties")
+	d.diffProperties(o, n, ptr, "patternProperties")
+	d.diffSubschema(o, n, ptr, "additionalProperties")
+	d.diffSubschema(o, n, ptr, "propertyNames")
+	d.diffItems(o, n, ptr)
+	d.diffSubschema(o, n, ptr, "additionalItems")
+	for _, kw := range []string{"allOf", "anyOf", "oneOf"} {
+		d.diffBranches(o, n, ptr, kw)
+	}
+	for _, kw := range definitionsKeywords {
+		ov, _ := o[kw].(map[string]interface{})
+		nv, _ := n[kw].(map[string]interface{})
+		d.diffMembers(ov, nv, ptr+"/"+kw, "definition")
+	}
+
+	// the keywords this differ does not understand
+	for _, kw := range unionKeys(o, n) {
+		if isDiffedKeyword(kw) {
+			continue
+		}
+		oldVal, oldOk := o[kw]
+		newVal, newOk := n[kw]
+		if oldOk == newOk && canonicalString(oldVal) == canonicalString(newVal) {
+			continue
+		}
+		d.guess(ptr+"/"+kw, ChangeTightened, valueChange(oldVal, newVal, oldOk, newOk))
+	}
+}
+
+// diffRequired appends the names added to and removed from "required"
+func (d *schemaDiffer) diffRequired(o, n map[string]interface{}, ptr string) {
+	oldRequired, newRequired := requiredSet(o), requiredSet(n)
+	for _, name := range unionKeys(boolsToMap(oldRequired), boolsToMap(newRequired)) {
+		switch {
+		case newRequired[name] && !oldRequired[name]:
+			d.add(ptr+"/"+KeyRequired, ChangeAddedRequired, fmt.Sprintf("%q is now required", name))
+		case oldRequired[name] && !newRequired[name]:
+			d.add(ptr+"/"+KeyRequired, ChangeLoosened, fmt.Sprintf("%q is no longer required", name))
+		}
+	}
+}
+
+// diffProperties appends the differences of the "properties" or the
+// "patternProperties" of two versions of a schema. The value of a property
+// whose definition is removed is checked against "additionalProperties".
+func (d *schemaDiffer) diffProperties(o, n map[string]interface{}, ptr string, kw string) {
+	op, _ := o[kw].(map[string]interface{})
+	np, _ := n[kw].(map[string]interface{})
+	for _, name := range unionKeys(op, np) {
+		p := ptr + "/" + kw + "/" + escapePtrToken(name)
+		ov, inOld := op[name]
+		nv, inNew := np[name]
+		switch {
+		case inOld && inNew:
+			d.diff(ov, nv, p)
+		case inOld && isTrueSchema(n, "additionalProperties"):
+			d.add(p, ChangeLoosened, "definition removed")
+		case inOld:
+			d.guess(p, ChangeTightened, "definition removed, the values are checked against additionalProperties")
+		case kw == "properties" && o["additionalProperties"] == false:
+			d.add(p, ChangeLoosened, "property added")
+		case isTrueSchema(o, "additionalProperties"):
+			d.add(p, ChangeTightened, "definition added")
+		default:
+			d.guess(p, ChangeTightened, "definition added")
+		}
+	}
+}
+
+// diffSubschema appends the differences of a keyword that holds a schema,
+// a missing keyword being the true schema
+func (d *schemaDiffer) diffSubschema(o, n map[string]interface{}, ptr string, kw string) {
+	ov, inOld := o[kw]
+	nv, inNew := n[kw]
+	if !inOld && !inNew {
+		return
+	}
+	if !inOld {
+		ov = true
+	}
+	if !inNew {
+		nv = true
+	}
+	d.diff(ov, nv, ptr+"/"+kw)
+}
+
+// diffItems appends the differences of the "items" and "prefixItems" of
+// two versions of a schema
+func (d *schemaDiffer) diffItems(o, n map[string]interface{}, ptr string) {
+	for _, kw := range []string{"items", "prefixItems"} {
+		oa, oldIsArray := o[kw].([]interface{})
+		na, newIsArray := n[kw].([]interface{})
+		switch {
+		case oldIsArray && newIsArray:
+			for i := 0; i < len(oa) || i < len(na); i++ {
+				p := ptr + "/" + kw + "/" + strconv.Itoa(i)
+				switch {
+				case i < len(oa) && i < len(na):
+					d.diff(oa[i], na[i], p)
+				case i < len(oa) && isTrueSchema(n, "additionalItems"):
+					d.add(p, ChangeLoosened, "definition removed")
+				case i < len(oa):
+					d.guess(p, ChangeTightened, "definition removed, the items are checked against additionalItems")
+				case isTrueSchema(o, "additionalItems"):
+					d.add(p, ChangeTightened, "definition added")
+				default:
+					d.guess(p, ChangeTightened, "definition added")
+				}
+			}
+		case !oldIsArray && !newIsArray:
+			d.diffSubschema(o, n, ptr, kw)
+		default:
+			d.guess(ptr+"/"+kw, ChangeTightened, "changed between a schema and a list of schemas")
+		}
+	}
+}
+
+// diffBranches appends the differences of the "allOf", "anyOf" or "oneOf"
+// of two versions of a schema. Branches that are the same in both are
+// matched first; the other branches are then paired in order.
+func (d *schemaDiffer) diffBranches(o, n map[string]interface{}, ptr string, kw string) {
+	oa, _ := o[kw].([]interface{})
+	na, _ := n[kw].([]interface{})
+	if len(oa) == 0 && len(na) == 0 {
+		return
+	}
+	oldLeft, newLeft := unmatchedBranches(oa, na), unmatchedBranches(na, oa)
+	for len(oldLeft) > 0 && len(newLeft) > 0 {
+		start := len(d.changes)
+		d.diff(oa[oldLeft[0]], na[newLeft[0]], ptr+"/"+kw+"/"+strconv.Itoa(newLeft[0]))
+		if kw == "oneOf" {
+			// a document that matched this branch and another one may
+			// match only one of them once this branch is tightened
+			for i := start; i < len(d.changes); i++ {
+				d.changes[i].Uncertain = true
+			}
+		}
+		oldLeft, newLeft = oldLeft[1:], newLeft[1:]
+	}
+	// a branch of "allOf" constrains the value, one of "anyOf" allows it
+	removed, added := ChangeLoosened, ChangeTightened
+	switch kw {
+	case "anyOf":
+		removed, added = ChangeTightened, ChangeLoosened
+	case "oneOf":
+		removed, added = ChangeTightened, ChangeTightened
+	}
+	// a document that matched two branches of "oneOf" may match a single
+	// one once a branch is removed, and one that matched a single branch
+	// may match two once a branch is added
+	add := d.add
+	if kw == "oneOf" {
+		add = d.guess
+	}
+	for _, i := range oldLeft {
+		add(ptr+"/"+kw+"/"+strconv.Itoa(i), removed, "branch removed")
+	}
+	for _, i := range newLeft {
+		add(ptr+"/"+kw+"/"+strconv.Itoa(i), added, "branch added")
+	}
+}
+
+// unmatchedBranches returns the indices of the branches of a that are not
+// in b, each branch of b matching one branch of a
+func unmatchedBranches(a, b []interface{}) []int {
+	count := make(map[string]int, len(b))
+	for _, branch := range b {
+		count[canonicalString(branch)]++
+	}
+	var left []int
+	for i, branch := range a {
+		c := canonicalString(branch)
+		if count[c] > 0 {
+			count[c]--
+			continue
+		}
+		left = append(left, i)
+	}
+	return left
+}
+
+// diffMembers appends the differences of the schemas held by two versions
+// of a map of schemas, such as "definitions"
+func (d *schemaDiffer) diffMembers(o, n map[string]interface{}, ptr string, what string) {
+	for _, name := range unionKeys(o, n) {
+		p := ptr + "/" + escapePtrToken(name)
+		ov, inOld := o[name]
+		nv, inNew := n[name]
+		switch {
+		case inOld && inNew:
+			d.diff(ov, nv, p)
+		case inOld:
+			d.guess(p, ChangeTightened, what+" removed")
+		}
+	}
+}
+
+// isTrueSchema returns true if the keyword of the schema is missing or true
+func isTrueSchema(schema map[string]interface{}, kw string) bool {
+	v, ok := schema[kw]
+	return !ok || v == true
+}
+
+// diffedKeywords are the keywords compared by schemaDiffer, and the
+// annotations, that do not constrain values
+var diffedKeywords = []string{
+	"type", "enum", "const", "minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum",
+	"minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties",
+	"multipleOf", "uniqueItems", "pattern", "format",
+	"required", "properties", "patternProperties", "additionalProperties", "propertyNames",
+	"items", "prefixItems", "additionalItems", "allOf", "anyOf", "oneOf", "definitions", "$defs",
+	"title", "description", "default", "examples", "$comment", "$schema", "$id", "id",
+}
+
+// isDiffedKeyword returns true if the keyword is one of diffedKeywords
+func isDiffedKeyword(kw string) bool {
+	for _, k := range diffedKeywords {
+		if kw == k {
+			return true
+		}
+	}
+	return false
+}
+
+// unionKeys returns the keys of both maps in sorted order
+func unionKeys(a, b map[string]interface{}) []string {
+	union := make(map[string]interface{}, len(a)+len(b))
+	for k := range a {
+		union[k] = true
+	}
+	for k := range b {
+		union[k] = true
+	}
+	return sortedKeys(union)
+}
+
+// boolsToMap returns a set as a map of interface values
+func boolsToMap(set map[string]bool) map[string]interface{} {
+	m := make(map[string]interface{}, len(set))
+	for k := range set {
+		m[k] = true
+	}
+	return m
+}
@@ -0,0 +1,114 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	var testSchema = `{"vmDeviceDefine": {"vm": {"type": "object", "additionalProperties": false, "required": ["vcpus"],
+		"properties": {
+			"vcpus": {"type": "integer", "minimum": 2, "maximum": 16},
+			"name": {"type": "string", "pattern": "^[a-z]+$"},
+			"nic": {"oneOf": [{"type": "string"}, {"type": "null"}]},
+			"tier": {"enum": ["gold", "silver"]}}}}}`
+
+	testTable := []struct {
+		description      string
+		old, new         string
+		expectedChanges  []string
+		expectedBreaking int
+	}{
+		{"Same schema", "", "", []string{}, 0},
+		{"Required property added", `"required": ["vcpus"]`, `"required": ["vcpus", "name"]`,
+			[]string{`#/vmDeviceDefine/vm/required: addedRequired: "name" is now required`}, 1},
+		{"Enum value added", `["gold", "silver"]`, `["gold", "silver", "bronze"]`,
+			[]string{`#/vmDeviceDefine/vm/properties/tier/enum: loosened: changed from ["gold","silver"] to ["gold","silver","bronze"]`}, 0},
+		{"Maximum lowered", `"maximum": 16`, `"maximum": 8`,
+			[]string{`#/vmDeviceDefine/vm/properties/vcpus/maximum: tightened: changed from 16 to 8`}, 1},
+		{"Pattern changed", `"^[a-z]+$"`, `"^[a-z0-9]+$"`,
+			[]string{`#/vmDeviceDefine/vm/properties/name/pattern: tightened: changed from "^[a-z]+$" to "^[a-z0-9]+$"`}, 1},
+		{"Additional properties allowed", `"additionalProperties": false`, `"additionalProperties": true`,
+			[]string{`#/vmDeviceDefine/vm/additionalProperties: loosened: schema accepted no value`}, 0},
+		{"Property added", `"properties": {`, `"properties": {"memory": {"type": "integer"},`,
+			[]string{`#/vmDeviceDefine/vm/properties/memory: loosened: property added`}, 0},
+		{"Branch removed", `[{"type": "string"}, {"type": "null"}]`, `[{"type": "string"}]`,
+			[]string{`#/vmDeviceDefine/vm/properties/nic/oneOf/1: tightened: branch removed`}, 1},
+		{"Branch loosened", `{"type": "null"}`, `{"type": ["null", "integer"]}`,
+			[]string{`#/vmDeviceDefine/vm/properties/nic/oneOf/1/type: loosened: changed from "null" to ["null","integer"]`}, 0},
+		{"Schema added to the container", `{"vmDeviceDefine": {`, `{"diskDeviceDefine": {"type": "object"}, "vmDeviceDefine": {`,
+			[]string{`#/diskDeviceDefine: loosened: schema added`}, 0},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			newSchema := strings.Replace(testSchema, tdr.old, tdr.new, 1)
+			diff, err := jsondatavalidator.DiffSchemas([]byte(testSchema), []byte(newSchema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := make([]string, 0)
+			for _, c := range diff.Changes {
+				changes = append(changes, c.String())
+			}
+			if !reflect.DeepEqual(tdr.expectedChanges, changes) {
+				t.Errorf("expected %v, got %v", tdr.expectedChanges, changes)
+			}
+			if len(diff.Breaking()) != tdr.expectedBreaking {
+				t.Errorf("expected %d breaking changes, got %v", tdr.expectedBreaking, diff.Breaking())
+			}
+		})
+	}
+}
+
+func TestDiffSchemasUncertain(t *testing.T) {
+	testTable := []struct {
+		description       string
+		old, new          string
+		expectedUncertain []string
+	}{
+		{"Maximum lowered", `{"maximum": 16}`, `{"maximum": 8}`, []string{}},
+		{"Pattern added", `{"type": "string"}`, `{"type": "string", "pattern": "^vm-"}`, []string{}},
+		{"Pattern changed", `{"pattern": "^vm-"}`, `{"pattern": ".*"}`, []string{"#/pattern"}},
+		{"Enum values removed", `{"enum": [1, 2, 3]}`, `{"enum": [1, 2]}`, []string{}},
+		{"Enum values replaced", `{"enum": [1, 2]}`, `{"enum": [1, 3]}`, []string{"#/enum"}},
+		{"MultipleOf multiplied", `{"multipleOf": 2}`, `{"multipleOf": 4}`, []string{}},
+		{"MultipleOf changed", `{"multipleOf": 2}`, `{"multipleOf": 3}`, []string{"#/multipleOf"}},
+		{"Unknown keyword added", `{}`, `{"not": {"const": 3}}`, []string{"#/not"}},
+		{"OneOf branch tightened", `{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`,
+			`{"oneOf": [{"type": "integer"}, {"minimum": 4}]}`, []string{"#/oneOf/1/minimum"}},
+		{"AllOf branch added", `{"allOf": [{"minimum": 2}]}`, `{"allOf": [{"minimum": 2}, {"maximum": 8}]}`, []string{}},
+		{"OneOf branch added", `{"oneOf": [{"minimum": 2}]}`, `{"oneOf": [{"minimum": 2}, {"maximum": 8}]}`, []string{"#/oneOf/1"}},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			diff, err := jsondatavalidator.DiffSchemas([]byte(tdr.old), []byte(tdr.new))
+			if err != nil {
+				t.Fatal(err)
+			}
+			uncertain := make([]string, 0)
+			for _, c := range diff.Changes {
+				if c.Uncertain {
+					uncertain = append(uncertain, c.Pointer)
+				}
+			}
+			if len(diff.Changes) == 0 {
+				t.Error("expected changes")
+			}
+			if !reflect.DeepEqual(tdr.expectedUncertain, uncertain) {
+				t.Errorf("expected %v, got %v", tdr.expectedUncertain, uncertain)
+			}
+		})
+	}
+}
+
+func TestDiffSchemasErrors(t *testing.T) {
+	if _, err := jsondatavalidator.DiffSchemas([]byte(`{"type": "object"}`), []byte(`{"type":`)); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,218 @@
+package jsondatavalidator
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Position is a 1-based line and column in a json or yaml buffer. The
+// column counts characters, not bytes.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// String returns the position as "line 14, column 9"
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// SourceRange is the part of a json or yaml buffer that holds a value.
+// End is the position just past the last character of the value.
+type SourceRange struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// String returns the start of the range as "line 14, column 9"
+func (r SourceRange) String() string {
+	return r.Start.String()
+}
+
+// SourceMap maps the json-pointers, for e.g; "#/vm/vcpus", of the values in
+// a json or yaml buffer to the part of the buffer that holds them
+type SourceMap map[string]SourceRange
+
+// NewSourceMap parses the json or yaml buffer and records the source range
+// of every value in it
+func NewSourceMap(buf []byte) (SourceMap, error) {
+	root, err := parseYAMLNode(buf)
+	if err != nil {
+		return nil, err
+	}
+	sm := make(SourceMap)
+	if root != nil {
+		sm.add(root, "#", splitLines(buf))
+	}
+	return sm, nil
+}
+
+// Lookup returns the source range of the value at the json-pointer. If the
+// pointer does not refer to a value in the buffer, for e.g; when it refers
+// to a missing property, the range of the closest enclosing value is
+// returned instead.
+func (sm SourceMap) Lookup(ptr string) (SourceRange, bool) {
+	for {
+		if r, ok := sm[ptr]; ok {
+			return r, true
+		}
+		i := strings.LastIndexByte(ptr, '/')
+		if i < 0 {
+			return SourceRange{}, false
+		}
+		ptr = ptr[:i]
+	}
+}
+
+// add records the range of the node and of all its descendants and
+// returns the range of the node
+func (sm SourceMap) add(n *yamlv3.Node, ptr string, lines [][]rune) SourceRange {
+	n = resolveAlias(n)
+	start := Position{Line: n.Line, Column: n.Column}
+	r := SourceRange{Start: start, End: start}
+	switch n.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			r.End = sm.add(n.Content[i+1], ptr+"/"+escapePtrToken(n.Content[i].Value), lines).End
+		}
+		r.End = closeFlowCollection(n, r.End, lines)
+	case yamlv3.SequenceNode:
+		for i, c := range n.Content {
+			r.End = sm.add(c, ptr+"/"+strconv.Itoa(i), lines).End
+		}
+		r.End = closeFlowCollection(n, r.End, lines)
+	case yamlv3.ScalarNode:
+		r.End = scalarEnd(n, lines)
+	}
+	sm[ptr] = r
+	return r
+}
+
+// parseYAMLNode parses a json or yaml buffer and returns the root node of
+// its first document, nil if the buffer is empty
+func parseYAMLNode(buf []byte) (*yamlv3.Node, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yamlv3.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return resolveAlias(doc.Content[0]), nil
+}
+
+// resolveAlias returns the node an alias node refers to
+func resolveAlias(n *yamlv3.Node) *yamlv3.Node {
+	for n.Kind == yamlv3.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// escapePtrToken escapes a map key for use in a json-pointer
+func escapePtrToken(tok string) string {
+	tok = strings.Replace(tok, "~", "~0", -1)
+	return strings.Replace(tok, "/", "~1", -1)
+}
+
+// splitLines splits a buffer into lines of characters
+func splitLines(buf []byte) [][]rune {
+	raw := bytes.Split(buf, []byte("\n"))
+	lines := make([][]rune, len(raw))
+	for i, l := range raw {
+		lines[i] = []rune(string(bytes.TrimSuffix(l, []byte("\r"))))
+	}
+	return lines
+}
+
+// charAt returns the character at the 1-based position, 0 past the end of a line
+func charAt(lines [][]rune, p Position) rune {
+	if p.Line < 1 || p.Line > len(lines) || p.Column < 1 || p.Column > len(lines[p.Line-1]) {
+		return 0
+	}
+	return lines[p.Line-1][p.Column-1]
+}
+
+// scalarEnd returns the position just past a scalar by scanning the source,
+// as the parser only records where a node starts
+func scalarEnd(n *yamlv3.Node, lines [][]rune) Position {
+	p := Position{Line: n.Line, Column: n.Column}
+	switch n.Style {
+	case yamlv3.DoubleQuotedStyle, yamlv3.SingleQuotedStyle:
+		quote := charAt(lines, p)
+		p.Column++
+		for p.Line <= len(lines) {
+			c := charAt(lines, p)
+			switch {
+			case c == 0:
+				p = Position{Line: p.Line + 1, Column: 1}
+				continue
+			case c == '\\' && quote == '"':
+				p.Column++
+			case c == quote && quote == '\'' && charAt(lines, Position{p.Line, p.Column + 1}) == '\'':
+				p.Column++
+			case c == quote:
+				return Position{Line: p.Line, Column: p.Column + 1}
+			}
+			p.Column++
+		}
+		return p
+	case yamlv3.LiteralStyle, yamlv3.FoldedStyle:
+		// the content starts on the line after the indicator
+		nl := strings.Count(strings.TrimRight(n.Value, "\n"), "\n")
+		end := Position{Line: n.Line + 1 + nl}
+		if end.Line <= len(lines) {
+			end.Column = len(lines[end.Line-1]) + 1
+		}
+		return end
+	}
+	// a plain scalar ends at the end of the line, at a comment, or at a
+	// flow indicator
+	for {
+		c := charAt(lines, p)
+		if c == 0 || c == ',' || c == ']' || c == '}' ||
+			(c == '#' && p.Column > 1 && lines[p.Line-1][p.Column-2] == ' ') {
+			break
+		}
+		p.Column++
+	}
+	for p.Column > n.Column && charAt(lines, Position{p.Line, p.Column - 1}) == ' ' {
+		p.Column--
+	}
+	return p
+}
+
+// closeFlowCollection moves the end of a flow mapping or sequence past its
+// closing bracket
+func closeFlowCollection(n *yamlv3.Node, end Position, lines [][]rune) Position {
+	if n.Style&yamlv3.FlowStyle == 0 {
+		return end
+	}
+	closing := '}'
+	if n.Kind == yamlv3.SequenceNode {
+		closing = ']'
+	}
+	p := end
+	if len(n.Content) == 0 {
+		p.Column++
+	}
+	for p.Line <= len(lines) {
+		c := charAt(lines, p)
+		switch c {
+		case closing:
+			return Position{Line: p.Line, Column: p.Column + 1}
+		case 0:
+			p = Position{Line: p.Line + 1, Column: 1}
+			continue
+		case ' ', '\t', ',':
+		default:
+			return end
+		}
+		p.Column++
+	}
+	return end
+}
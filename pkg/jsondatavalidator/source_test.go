@@ -0,0 +1,97 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestNewSourceMap(t *testing.T) {
+	var testYAMLData = []byte(`
+# VM template
+vm:
+  name: "web-01"
+  vcpus: 4   # cpus
+  disks:
+    - sda
+    - 'sd''b'
+`)
+	var testJSONData = []byte(`{
+  "vm": {"name": "web-01", "vcpus": 4,
+    "disks": ["sda", "sdb"], "tags": {}}
+}`)
+
+	pos := func(l1, c1, l2, c2 int) jsondatavalidator.SourceRange {
+		return jsondatavalidator.SourceRange{
+			Start: jsondatavalidator.Position{Line: l1, Column: c1},
+			End:   jsondatavalidator.Position{Line: l2, Column: c2},
+		}
+	}
+	testTable := []struct {
+		description   string
+		testData      []byte
+		ptr           string
+		expectedRange jsondatavalidator.SourceRange
+	}{
+		{"YAML quoted scalar", testYAMLData, "#/vm/name", pos(4, 9, 4, 17)},
+		{"YAML plain scalar with comment", testYAMLData, "#/vm/vcpus", pos(5, 10, 5, 11)},
+		{"YAML sequence item", testYAMLData, "#/vm/disks/1", pos(8, 7, 8, 14)},
+		{"YAML mapping", testYAMLData, "#/vm", pos(4, 3, 8, 14)},
+		{"YAML missing property", testYAMLData, "#/vm/memory", pos(4, 3, 8, 14)},
+		{"JSON scalar", testJSONData, "#/vm/vcpus", pos(2, 37, 2, 38)},
+		{"JSON array", testJSONData, "#/vm/disks", pos(3, 14, 3, 28)},
+		{"JSON empty object", testJSONData, "#/vm/tags", pos(3, 38, 3, 40)},
+		{"JSON object", testJSONData, "#/vm", pos(2, 9, 3, 41)},
+		{"JSON root", testJSONData, "#", pos(1, 1, 4, 2)},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			sm, err := jsondatavalidator.NewSourceMap(tdr.testData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, ok := sm.Lookup(tdr.ptr)
+			if !ok || r != tdr.expectedRange {
+				t.Errorf("expected %+v, got %+v", tdr.expectedRange, r)
+			}
+		})
+	}
+}
+
+func TestValidateWithResultSourceRanges(t *testing.T) {
+	v, err := jsondatavalidator.NewValidator(strings.NewReader(testVMSchema), "sch.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testTable := []struct {
+		description    string
+		jsonval        []byte
+		expectedOutput string
+	}{
+		{"YAML", []byte("vm:\n  vcpus: 4\n  memory: 100\n"),
+			"line 3, column 11: I[#/vm/memory] S[#/properties/vm/properties/memory/minimum] must be >= 512 but found 100"},
+		{"JSON", []byte("{\"vm\": {\n  \"vcpus\": 4, \"proc\": 1}}"),
+			"line 1, column 8: I[#/vm] S[#/properties/vm/additionalProperties] additionalProperties 'proc' not allowed"},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			res, err := v.ValidateWithResult(tdr.jsonval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Valid() {
+				t.Fatal("expected violations")
+			}
+			out := res.Violations[0].LocatedString()
+			t.Log(out)
+			if out != tdr.expectedOutput {
+				t.Errorf("expected %s", tdr.expectedOutput)
+			}
+		})
+	}
+}
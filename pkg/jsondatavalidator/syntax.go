@@ -0,0 +1,180 @@
+package jsondatavalidator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Names of the built-in placeholder syntaxes
+const (
+	// SyntaxDollar is "$name". A name is made of letters, digits and
+	// underscores, so that "web-$env-$index" holds two placeholders. "$$"
+	// stands for a literal "$".
+	SyntaxDollar = "dollar"
+	// SyntaxDollarBrace is "${name}". "$$" stands for a literal "$".
+	SyntaxDollarBrace = "dollarBrace"
+	// SyntaxGoTemplate is "{{ .name }}". "{{{{" stands for a literal "{{";
+	// this escape is particular to this package, the {{"{{"}} of Go
+	// templates is not understood.
+	SyntaxGoTemplate = "goTemplate"
+	// SyntaxJinja is "{{ name }}". "{{{{" stands for a literal "{{"; this
+	// escape is particular to this package, the {% raw %} blocks of Jinja
+	// are not understood.
+	SyntaxJinja = "jinja"
+	// SyntaxChevron is ">>name<<". ">>>>" stands for a literal ">>".
+	SyntaxChevron = "chevron"
+)
+
+// PlaceholderMatch is a placeholder found in a string by a PlaceholderSyntax
+type PlaceholderMatch struct {
+	// Start and End are the byte offsets of the placeholder in the string
+	Start int
+	End   int
+	// Text names the parameter, with the declarations of the placeholder,
+	// for e.g; "memory" or "memory:integer=1024"
+	Text string
+}
+
+// PlaceholderSyntax finds the placeholders of a parameterized template.
+// Implementations must be safe for concurrent use.
+type PlaceholderSyntax interface {
+	// Name identifies the syntax, for e.g; SyntaxDollar
+	Name() string
+	// FindAll returns the placeholders of a string, in order. Escaped
+	// delimiters are not placeholders.
+	FindAll(s string) []PlaceholderMatch
+	// Unescape returns the text a string that holds no placeholder stands
+	// for, i.e; with escaped delimiters replaced by the delimiters
+	Unescape(s string) string
+}
+
+// PatternSyntax is a PlaceholderSyntax whose placeholders can be matched by
+// a regexp, for schemas that have to accept placeholders, such as those of
+// GeneratePlaceholderTolerantSchemaWithSyntax. The built-in syntaxes and
+// those of NewRegexpSyntax implement it.
+type PatternSyntax interface {
+	PlaceholderSyntax
+	// Pattern returns a regexp that matches a placeholder
+	Pattern() string
+}
+
+// delimitedSyntax is a PlaceholderSyntax whose placeholders are matched by
+// a regexp, and whose literal delimiter is escaped by doubling it
+type delimitedSyntax struct {
+	name    string
+	pattern string
+	escape  string
+	literal string
+	// rxp matches an escape or a placeholder, whose last capture group
+	// that participates in the match names the parameter
+	rxp *regexp.Regexp
+}
+
+// newDelimitedSyntax returns a syntax for the placeholder pattern. An empty
+// escape means the syntax has none.
+func newDelimitedSyntax(name, pattern, escape, literal string) (*delimitedSyntax, error) {
+	expr := pattern
+	if escape != "" {
+		// the escape comes first, to win over a placeholder at the same offset
+		expr = regexp.QuoteMeta(escape) + "|" + pattern
+	}
+	rxp, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &delimitedSyntax{name: name, pattern: pattern, escape: escape, literal: literal, rxp: rxp}, nil
+}
+
+// NewRegexpSyntax returns a PlaceholderSyntax for a placeholder regexp, such
+// as the regExpStr argument of GenerateJSONSchemaFromParameterizedTemplate.
+// The last capture group of the regexp that participates in a match, if
+// any, names the parameter. The syntax has no escape.
+func NewRegexpSyntax(regExpStr string) (PlaceholderSyntax, error) {
+	return newDelimitedSyntax("regexp", regExpStr, "", "")
+}
+
+func (s *delimitedSyntax) Name() string {
+	return s.name
+}
+
+func (s *delimitedSyntax) FindAll(str string) []PlaceholderMatch {
+	var matches []PlaceholderMatch
+	for _, loc := range s.rxp.FindAllStringSubmatchIndex(str, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		raw := str[loc[0]:loc[1]]
+		if s.escape != "" && raw == s.escape {
+			continue
+		}
+		matches = append(matches, PlaceholderMatch{Start: loc[0], End: loc[1], Text: placeholderName(submatches(str, loc))})
+	}
+	return matches
+}
+
+func (s *delimitedSyntax) Unescape(str string) string {
+	if s.escape == "" {
+		return str
+	}
+	return strings.Replace(str, s.escape, s.literal, -1)
+}
+
+func (s *delimitedSyntax) Pattern() string {
+	return s.pattern
+}
+
+// submatches returns the text of the matches of FindStringSubmatchIndex
+func submatches(s string, loc []int) []string {
+	res := make([]string, len(loc)/2)
+	for i := range res {
+		if loc[2*i] >= 0 {
+			res[i] = s[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return res
+}
+
+var (
+	syntaxesMu sync.RWMutex
+	syntaxes   = make(map[string]PlaceholderSyntax)
+)
+
+func init() {
+	for _, s := range []struct{ name, pattern, escape, literal string }{
+		{SyntaxDollar, `\$([A-Za-z_][A-Za-z0-9_]*\??(?:\|[-A-Za-z0-9_.]+)?)`, "$$", "$"},
+		{SyntaxDollarBrace, `\$\{([^{}$]+)\}`, "$$", "$"},
+		{SyntaxGoTemplate, `\{\{\s*\.([^{}\s]+)\s*\}\}`, "{{{{", "{{"},
+		{SyntaxJinja, `\{\{\s*([^{}\s.][^{}\s]*)\s*\}\}`, "{{{{", "{{"},
+		{SyntaxChevron, `>>([^<>\s]+)<<`, ">>>>", ">>"},
+	} {
+		syntax, err := newDelimitedSyntax(s.name, s.pattern, s.escape, s.literal)
+		if err != nil {
+			panic(err)
+		}
+		syntaxes[s.name] = syntax
+	}
+}
+
+// RegisterPlaceholderSyntax makes a syntax available by its name through
+// LookupPlaceholderSyntax. It returns an error if a syntax is already
+// registered with the same name.
+func RegisterPlaceholderSyntax(s PlaceholderSyntax) error {
+	syntaxesMu.Lock()
+	defer syntaxesMu.Unlock()
+	if _, ok := syntaxes[s.Name()]; ok {
+		return fmt.Errorf("placeholder syntax %q is already registered", s.Name())
+	}
+	syntaxes[s.Name()] = s
+	return nil
+}
+
+// LookupPlaceholderSyntax returns the syntax registered with the name, for
+// e.g; SyntaxDollarBrace
+func LookupPlaceholderSyntax(name string) (PlaceholderSyntax, bool) {
+	syntaxesMu.RLock()
+	defer syntaxesMu.RUnlock()
+	s, ok := syntaxes[name]
+	return s, ok
+}
@@ -0,0 +1,217 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestPlaceholderSyntaxes(t *testing.T) {
+	testInputParams := map[string]interface{}{"vcpus": 4, "env": "prod"}
+
+	testTable := []struct {
+		description    string
+		syntaxName     string
+		template       []byte
+		expectedNames  []string
+		expectedOutput string
+	}{
+		{"Dollar", jsondatavalidator.SyntaxDollar,
+			[]byte(`{"vcpus": "$vcpus", "name": "web-$env", "price": "$$5", "memory": "$memory|1024"}`),
+			[]string{"vcpus", "env", "memory"},
+			`{"memory":1024,"name":"web-prod","price":"$5","vcpus":4}`},
+		{"Dollar brace", jsondatavalidator.SyntaxDollarBrace,
+			[]byte(`{"vcpus": "${vcpus}", "name": "web-${env}", "price": "$${env}", "memory": "${memory:integer=1024}"}`),
+			[]string{"vcpus", "env", "memory"},
+			`{"memory":1024,"name":"web-prod","price":"${env}","vcpus":4}`},
+		{"Go template", jsondatavalidator.SyntaxGoTemplate,
+			[]byte("vcpus: '{{ .vcpus }}'\nname: 'web-{{.env}}'\nliteral: '{{{{ .env }}'\nplain: '{{ env }}'\n"),
+			[]string{"vcpus", "env"},
+			`{"literal":"{{ .env }}","name":"web-prod","plain":"{{ env }}","vcpus":4}`},
+		{"Jinja", jsondatavalidator.SyntaxJinja,
+			[]byte("vcpus: '{{ vcpus }}'\nname: 'web-{{env}}'\nliteral: '{{{{ env }}'\nplain: '{{ .env }}'\n"),
+			[]string{"vcpus", "env"},
+			`{"literal":"{{ env }}","name":"web-prod","plain":"{{ .env }}","vcpus":4}`},
+		{"Chevron, not valid yaml", jsondatavalidator.SyntaxChevron,
+			[]byte("vcpus: >>vcpus<<\nname: web->>env<<\nliteral: '>>>>env<<'\n"),
+			[]string{"vcpus", "env"},
+			`{"literal":"\u003e\u003eenv\u003c\u003c","name":"web-prod","vcpus":4}`},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			syntax, ok := jsondatavalidator.LookupPlaceholderSyntax(tdr.syntaxName)
+			if !ok {
+				t.Fatalf("syntax %q is not registered", tdr.syntaxName)
+			}
+			phs, err := jsondatavalidator.DiscoverPlaceholdersWithSyntax(tdr.template, syntax)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0)
+			for _, p := range phs {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(tdr.expectedNames, names) {
+				t.Errorf("expected %v, got %v", tdr.expectedNames, names)
+			}
+			out, err := jsondatavalidator.RenderParameterizedTemplateWithSyntax(tdr.template, syntax, testInputParams)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tdr.expectedOutput {
+				t.Errorf("expected %s, got %s", tdr.expectedOutput, out)
+			}
+		})
+	}
+}
+
+func TestGenerateJSONSchemaWithPlaceholderSyntax(t *testing.T) {
+	syntax, _ := jsondatavalidator.LookupPlaceholderSyntax(jsondatavalidator.SyntaxGoTemplate)
+	out, err := jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(
+		[]byte("vm:\n  name: '{{ .vmName }}'\n  disks:\n    - size: '{{ .size }}'\n"), testScopedNonParamSchema,
+		[]byte(`{"inputParam": {"type": "object"}}`), nil, "",
+		jsondatavalidator.WithPlaceholderSyntax(syntax), jsondatavalidator.WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(out, &schema); err != nil {
+		t.Fatal(err)
+	}
+	expectedProperties := map[string]interface{}{
+		"vmName": map[string]interface{}{"type": "string", "pattern": "^vm-"},
+		"size":   map[string]interface{}{"type": "integer", "minimum": float64(1)},
+	}
+	if !reflect.DeepEqual(expectedProperties, schema["properties"]) {
+		t.Errorf("expected %v, got %v", expectedProperties, schema["properties"])
+	}
+}
+
+// percentSyntax is a custom syntax, "%name%"
+type percentSyntax struct {
+	jsondatavalidator.PlaceholderSyntax
+}
+
+func (percentSyntax) Name() string {
+	return "percent"
+}
+
+func TestRegisterPlaceholderSyntax(t *testing.T) {
+	rs, err := jsondatavalidator.NewRegexpSyntax(`%([a-z]+)%`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jsondatavalidator.RegisterPlaceholderSyntax(percentSyntax{rs}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsondatavalidator.RegisterPlaceholderSyntax(percentSyntax{rs}); err == nil {
+		t.Error("expected an error registering a name twice")
+	}
+	if _, ok := jsondatavalidator.LookupPlaceholderSyntax("unknown"); ok {
+		t.Error("expected no syntax")
+	}
+	syntax, ok := jsondatavalidator.LookupPlaceholderSyntax("percent")
+	if !ok {
+		t.Fatal("expected the registered syntax")
+	}
+	out, err := jsondatavalidator.RenderParameterizedTemplateWithSyntax([]byte(`{"vcpus": "%vcpus%"}`), syntax,
+		map[string]interface{}{"vcpus": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"vcpus":4}` {
+		t.Errorf("expected the rendered template, got %s", out)
+	}
+}
+
+// bracketSyntax is a custom syntax, "[[name]]", that is not built on a
+// regexp. "[[[[" stands for a literal "[[".
+type bracketSyntax struct{}
+
+func (bracketSyntax) Name() string {
+	return "bracket"
+}
+
+func (bracketSyntax) FindAll(s string) []jsondatavalidator.PlaceholderMatch {
+	var matches []jsondatavalidator.PlaceholderMatch
+	for offset := 0; ; {
+		start := strings.Index(s[offset:], "[[")
+		if start < 0 {
+			return matches
+		}
+		start += offset
+		if strings.HasPrefix(s[start:], "[[[[") {
+			offset = start + 4
+			continue
+		}
+		end := strings.Index(s[start+2:], "]]")
+		if end < 0 {
+			return matches
+		}
+		end += start + 2
+		matches = append(matches, jsondatavalidator.PlaceholderMatch{Start: start, End: end + 2,
+			Text: strings.TrimSpace(s[start+2 : end])})
+		offset = end + 2
+	}
+}
+
+func (bracketSyntax) Unescape(s string) string {
+	return strings.Replace(s, "[[[[", "[[", -1)
+}
+
+func TestCustomPlaceholderSyntaxWithoutPattern(t *testing.T) {
+	var syntax jsondatavalidator.PlaceholderSyntax = bracketSyntax{}
+	if _, ok := syntax.(jsondatavalidator.PatternSyntax); ok {
+		t.Fatal("expected a syntax without a pattern")
+	}
+	template := []byte("vm:\n  name: '[[ vmName ]]'\n  title: 'web-[[env]] [[[[x]]'\n  disks:\n    - size: '[[size]]'\n")
+	phs, err := jsondatavalidator.DiscoverPlaceholdersWithSyntax(template, syntax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0)
+	for _, p := range phs {
+		names = append(names, p.Name)
+	}
+	if expected := []string{"vmName", "env", "size"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	out, err := jsondatavalidator.RenderParameterizedTemplateWithSyntax(template, syntax,
+		map[string]interface{}{"vmName": "vm-1", "env": "prod", "size": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"vm":{"disks":[{"size":10}],"name":"vm-1","title":"web-prod [[x]]"}}`; string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	out, err = jsondatavalidator.GenerateJSONSchemaFromParameterizedTemplate(
+		[]byte("vm:\n  name: '[[ vmName ]]'\n  disks:\n    - size: '[[size]]'\n"), testScopedNonParamSchema,
+		[]byte(`{"inputParam": {"type": "object"}}`), nil, "",
+		jsondatavalidator.WithPlaceholderSyntax(syntax), jsondatavalidator.WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(out, &schema); err != nil {
+		t.Fatal(err)
+	}
+	expectedProperties := map[string]interface{}{
+		"vmName": map[string]interface{}{"type": "string", "pattern": "^vm-"},
+		"size":   map[string]interface{}{"type": "integer", "minimum": float64(1)},
+	}
+	if !reflect.DeepEqual(expectedProperties, schema["properties"]) {
+		t.Errorf("expected %v, got %v", expectedProperties, schema["properties"])
+	}
+
+	if _, err := jsondatavalidator.GeneratePlaceholderTolerantSchemaWithSyntax(testScopedNonParamSchema, syntax); err == nil {
+		t.Error("expected an error for a syntax without a pattern")
+	}
+}
@@ -0,0 +1,174 @@
+package jsondatavalidator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// structuralKeywords are the keywords whose sub schemas define the
+// properties or items of a value, rather than the value itself
+var structuralKeywords = []string{
+	"properties", "patternProperties", "additionalProperties",
+	"items", "prefixItems", "additionalItems",
+}
+
+// GeneratePlaceholderTolerantSchema takes as arguments:
+// i) a non parameterized schema, for e.g; the schema of a "vmDeviceDefine"
+// ii) regExpStr: the regexp that matches a placeholder
+// The function returns the schema with each leaf, i.e; each schema that
+// defines a value rather than its properties or items, wrapped in a
+// "oneOf" with a schema that accepts a placeholder, for e.g;
+// {"oneOf": [{"type": "string", "pattern": "^(?:\\$(.*))$"}, {"type": "integer"}]}.
+// A leaf that also accepts strings is wrapped in an "anyOf" instead, since
+// a placeholder could match both of its branches. The returned schema
+// validates the structure of a parameterized template before any
+// parameters are supplied.
+func GeneratePlaceholderTolerantSchema(nonParamDefineJSONBuf []byte, regExpStr string) ([]byte, error) {
+	log.Debug()
+	syntax, err := NewRegexpSyntax(regExpStr)
+	if err != nil {
+		return nil, err
+	}
+	return GeneratePlaceholderTolerantSchemaWithSyntax(nonParamDefineJSONBuf, syntax)
+}
+
+// GeneratePlaceholderTolerantSchemaWithSyntax is
+// GeneratePlaceholderTolerantSchema for placeholders written in the syntax,
+// whose Pattern is the pattern of the schema that accepts a placeholder. An
+// error is returned if the syntax is not a PatternSyntax.
+func GeneratePlaceholderTolerantSchemaWithSyntax(nonParamDefineJSONBuf []byte, syntax PlaceholderSyntax) ([]byte, error) {
+	log.Debug()
+	ps, ok := syntax.(PatternSyntax)
+	if !ok {
+		return nil, fmt.Errorf("placeholder syntax %q has no pattern a schema can accept placeholders with", syntax.Name())
+	}
+	var doc interface{}
+	if err := json.Unmarshal(nonParamDefineJSONBuf, &doc); err != nil {
+		return nil, &UnmarshalError{Err: err}
+	}
+	placeholderSchema := map[string]interface{}{
+		"type":    "string",
+		"pattern": "^(?:" + ps.Pattern() + ")$",
+	}
+	r, e := json.Marshal(tolerateContainer(doc, placeholderSchema))
+	log.Debug(string(r), e)
+	return r, e
+}
+
+// tolerateContainer transforms the schemas held by a container of schemas
+func tolerateContainer(v interface{}, placeholderSchema map[string]interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	if isSchema(m) {
+		return tolerateSchema(m, placeholderSchema)
+	}
+	c := make(map[string]interface{}, len(m))
+	for k, sub := range m {
+		c[k] = tolerateContainer(sub, placeholderSchema)
+	}
+	return c
+}
+
+// tolerateSchema wraps the leaves of a schema in a "oneOf" or "anyOf" with
+// the placeholder schema
+func tolerateSchema(v interface{}, placeholderSchema map[string]interface{}) interface{} {
+	s, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	c := make(map[string]interface{}, len(s))
+	for k, sub := range s {
+		c[k] = sub
+	}
+	for _, kw := range definitionsKeywords {
+		if defs, ok := s[kw].(map[string]interface{}); ok {
+			c[kw] = tolerateSchemaMap(defs, placeholderSchema)
+		}
+	}
+	// a "$ref" is tolerant once the schema it refers to is
+	if _, ok := s["$ref"]; ok || !isSchema(s) {
+		return c
+	}
+	if !isStructural(s) {
+		wrapped := make(map[string]interface{})
+		// definitions stay where a "$ref" to them expects them
+		for _, kw := range definitionsKeywords {
+			if defs, ok := c[kw]; ok {
+				wrapped[kw] = defs
+				delete(c, kw)
+			}
+		}
+		kw := "oneOf"
+		if acceptsString(s) {
+			kw = "anyOf"
+		}
+		wrapped[kw] = []interface{}{placeholderSchema, c}
+		return wrapped
+	}
+	for _, kw := range []string{"properties", "patternProperties", "dependentSchemas"} {
+		if props, ok := s[kw].(map[string]interface{}); ok {
+			c[kw] = tolerateSchemaMap(props, placeholderSchema)
+		}
+	}
+	for _, kw := range []string{"additionalProperties", "additionalItems", "items", "prefixItems", "allOf"} {
+		switch sub := s[kw].(type) {
+		case map[string]interface{}:
+			c[kw] = tolerateSchema(sub, placeholderSchema)
+		case []interface{}:
+			a := make([]interface{}, len(sub))
+			for i := range sub {
+				a[i] = tolerateSchema(sub[i], placeholderSchema)
+			}
+			c[kw] = a
+		}
+	}
+	return c
+}
+
+// tolerateSchemaMap transforms each schema of a map of schemas, such as "properties"
+func tolerateSchemaMap(m map[string]interface{}, placeholderSchema map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, sub := range m {
+		c[k] = tolerateSchema(sub, placeholderSchema)
+	}
+	return c
+}
+
+// isStructural returns true if the schema defines the properties or items
+// of a value, or combines schemas that do with "allOf"
+func isStructural(s map[string]interface{}) bool {
+	for _, kw := range structuralKeywords {
+		if _, ok := s[kw]; ok {
+			return true
+		}
+	}
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, b := range allOf {
+			if m, ok := b.(map[string]interface{}); ok && isStructural(m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptsString returns true if the "type" of the schema allows strings
+func acceptsString(s map[string]interface{}) bool {
+	switch t := s["type"].(type) {
+	case nil:
+		return true
+	case string:
+		return t == "string"
+	case []interface{}:
+		for _, v := range t {
+			if v == "string" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestGeneratePlaceholderTolerantSchema(t *testing.T) {
+	var regExpStr = `\$[A-Za-z][-A-Za-z0-9_]*`
+	tolerant, err := jsondatavalidator.GeneratePlaceholderTolerantSchema(testJSONNonParamSchema, regExpStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(tolerant))
+
+	var doc struct {
+		VMDeviceDefine struct {
+			VM struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"vm"`
+		} `json:"vmDeviceDefine"`
+	}
+	if err := json.Unmarshal(tolerant, &doc); err != nil {
+		t.Fatal(err)
+	}
+	expectedVcpus := `{"oneOf":[{"pattern":"^(?:\\$[A-Za-z][-A-Za-z0-9_]*)$","type":"string"},{"maximum":16,"minimum":2,"multipleOf":2,"type":"integer"}]}`
+	if string(doc.VMDeviceDefine.VM.Properties["vcpus"]) != expectedVcpus {
+		t.Errorf("expected vcpus %s, got %s", expectedVcpus, doc.VMDeviceDefine.VM.Properties["vcpus"])
+	}
+
+	var vmSchema map[string]map[string]json.RawMessage
+	_ = json.Unmarshal(tolerant, &vmSchema)
+	v, err := jsondatavalidator.NewValidator(bytes.NewReader(vmSchema["vmDeviceDefine"]["vm"]), "vm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTable := []struct {
+		description string
+		template    []byte
+		expectedErr error
+	}{
+		{"Placeholders", []byte(`{"vcpus": "$vcpus", "memory": "$memory"}`), nil},
+		{"Placeholders and values", []byte(`{"vcpus": 4, "memory": "$memory"}`), nil},
+		{"Invalid value", []byte(`{"vcpus": 3, "memory": "$memory"}`), jsondatavalidator.ErrValidation},
+		{"Not a placeholder", []byte(`{"vcpus": "vcpus"}`), jsondatavalidator.ErrValidation},
+		{"Invalid structure", []byte(`{"vcpus": "$vcpus", "proc": "$proc"}`), jsondatavalidator.ErrValidation},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			err := v.Validate(tdr.template)
+			t.Log(err)
+			if !errors.Is(err, tdr.expectedErr) {
+				t.Errorf("expected %v, got %v", tdr.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := jsondatavalidator.GeneratePlaceholderTolerantSchema(testJSONNonParamSchema, `\$(`); err == nil {
+		t.Error("expected an error for an invalid regexp")
+	}
+}
@@ -0,0 +1,126 @@
+package jsondatavalidator
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+// Validator holds a compiled json schema so that the same schema can be
+// used to validate any number of json buffers without being recompiled.
+// A Validator is safe for concurrent use by multiple goroutines.
+type Validator struct {
+	url    string
+	draft  Draft
+	schema *jsonschema.Schema
+}
+
+// NewValidator takes as arguments:
+// i) a io.Reader object that contains the schema definition information
+// ii) a string (one of `schema.json` or `sch.json`) that represents if
+// schema definition is in a file or in memory
+// iii) optional settings, such as the draft to use (see WithDraft)
+// The function compiles the schema once and returns a Validator for it.
+// The draft is taken from the `$schema` keyword of the schema if present,
+// else from WithDraft, else DefaultDraft is used.
+func NewValidator(schemaDefAsReaderObj io.Reader, url string, opts ...ValidatorOption) (*Validator, error) {
+	log.Debug()
+	cfg := validatorConfig{draft: DefaultDraft}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	draft, err := cfg.draft.jsonschemaDraft()
+	if err != nil {
+		return nil, &CompileError{URL: url, Err: err}
+	}
+	buf, err := ioutil.ReadAll(schemaDefAsReaderObj)
+	if err != nil {
+		log.WithFields(log.Fields{"AddResourceError": err}).Error()
+		return nil, &AddResourceError{URL: url, Err: err}
+	}
+	if d, ok := DetectDraft(buf); ok {
+		cfg.draft = d
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = draft
+	// formats and content are asserted by every draft, not just by the
+	// drafts prior to 2019-09
+	compiler.AssertFormat = true
+	compiler.AssertContent = true
+	if err := compiler.AddResource(url, bytes.NewReader(buf)); err != nil {
+		log.WithFields(log.Fields{"AddResourceError": err}).Error()
+		return nil, &AddResourceError{URL: url, Err: err}
+	}
+	schema, err := compiler.Compile(url)
+	if err != nil {
+		log.WithFields(log.Fields{"CompileError": err}).Error()
+		return nil, &CompileError{URL: url, Err: err}
+	}
+	return &Validator{url: url, draft: cfg.draft, schema: schema}, nil
+}
+
+// Draft returns the draft the schema of the validator was compiled with
+func (v *Validator) Draft() Draft {
+	return v.draft
+}
+
+// Validate returns an error if the json buffer does not validate against
+// the compiled schema. As with ValidateJSONBufAgainstSchema, the message of
+// the returned *ValidationError is that of the last violation.
+func (v *Validator) Validate(jsonval []byte) error {
+	res, err := v.ValidateWithResult(jsonval)
+	if err != nil {
+		return err
+	}
+	if !res.Valid() {
+		return &ValidationError{Result: res}
+	}
+	return nil
+}
+
+// ValidateWithResult validates the json buffer against the compiled schema
+// and returns all violations, each with its position in the json buffer, in
+// the ValidationResult. An error is returned only if the json buffer could
+// not be processed.
+func (v *Validator) ValidateWithResult(jsonval []byte) (*ValidationResult, error) {
+	m, err := decodeJSONBuf(jsonval)
+	if err != nil {
+		return nil, err
+	}
+	res, err := v.validateInterface(m)
+	if err != nil {
+		return nil, err
+	}
+	res.locate(jsonval)
+	return res, nil
+}
+
+// validateInterface validates an already decoded json document
+func (v *Validator) validateInterface(m interface{}) (*ValidationResult, error) {
+	if zerr := v.schema.Validate(m); zerr != nil {
+		log.WithFields(log.Fields{"SchemaValidateInterfaceError": zerr}).Error()
+		ve, ok := zerr.(*jsonschema.ValidationError)
+		if !ok {
+			return nil, zerr
+		}
+		return newValidationResult(ve), nil
+	}
+	return &ValidationResult{Violations: make([]Violation, 0)}, nil
+}
+
+// decodeJSONBuf unmarshals a json (or yaml) buffer into the generic form
+// expected by the jsonschema library
+func decodeJSONBuf(jsonval []byte) (interface{}, error) {
+	var m interface{}
+	err := yaml.Unmarshal(jsonval, &m)
+	if err != nil {
+		log.WithFields(log.Fields{"UnMarshallError": err}).Error()
+		return nil, &UnmarshalError{Err: err}
+	}
+	return m, nil
+}
@@ -0,0 +1,139 @@
+// +build unit
+
+package jsondatavalidator_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vishwanathj/JSON-Parameterized-Data-Validator/pkg/jsondatavalidator"
+)
+
+func TestValidatorValidate(t *testing.T) {
+	v, err := jsondatavalidator.NewValidator(strings.NewReader(testVMSchema), "sch.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testTable := []struct {
+		description    string
+		jsonval        []byte
+		expectedOutput error
+	}{
+		{"Valid JSON", []byte(`{"vm": {"vcpus": 4, "memory": 1024}}`), nil},
+		{"Valid YAML", []byte("vm:\n  vcpus: $vcpus\n"), nil},
+		{"Invalid: missing required property", []byte(`{"vm": {"memory": 1024}}`), fmt.Errorf("I[#/vm] S[#/properties/vm/required] missing properties: 'vcpus'")},
+		{"Malformed JSON", []byte(`{"vm":`), jsondatavalidator.ErrUnmarshal},
+	}
+	// The same validator is reused for every case
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			err := v.Validate(tdr.jsonval)
+			if err != nil {
+				t.Log(err.Error())
+			}
+			if tdr.expectedOutput == nil {
+				if err != nil {
+					t.Errorf("expected no error")
+				}
+			} else if !errors.Is(err, tdr.expectedOutput) && (err == nil || err.Error() != tdr.expectedOutput.Error()) {
+				t.Errorf("%s", tdr.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestNewValidator(t *testing.T) {
+	testTable := []struct {
+		description    string
+		schema         string
+		url            string
+		expectedOutput error
+	}{
+		{"Valid schema", testVMSchema, "sch.json", nil},
+		{"Invalid URL", "dummy", "d", jsondatavalidator.ErrAddResource},
+		{"Invalid schema", `{"type": 1}`, "sch.json", jsondatavalidator.ErrCompile},
+	}
+	for i, tdr := range testTable {
+		t.Run(fmt.Sprintf("%d:%s", i, tdr.description), func(t *testing.T) {
+			v, err := jsondatavalidator.NewValidator(strings.NewReader(tdr.schema), tdr.url)
+			if tdr.expectedOutput == nil {
+				if err != nil || v == nil {
+					t.Errorf("expected a validator, got error %v", err)
+				}
+			} else if !errors.Is(err, tdr.expectedOutput) {
+				t.Errorf("%s", tdr.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestValidatorConcurrentValidate(t *testing.T) {
+	v, err := jsondatavalidator.NewValidator(strings.NewReader(testVMSchema), "sch.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			vcpus := 2 * (i%8 + 1)
+			if err := v.Validate([]byte(fmt.Sprintf(`{"vm": {"vcpus": %d}}`, vcpus))); err != nil {
+				errs <- err
+			}
+			if err := v.Validate([]byte(`{"vm": {"vcpus": 3}}`)); err == nil {
+				errs <- fmt.Errorf("expected vcpus 3 to be rejected")
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+var benchVMData = []byte(`{"vm": {"vcpus": 4, "memory": 1024}}`)
+
+// BenchmarkValidateJSONBufAgainstSchema compiles the schema on every call
+func BenchmarkValidateJSONBufAgainstSchema(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if err := jsondatavalidator.ValidateJSONBufAgainstSchema(benchVMData, strings.NewReader(testVMSchema), "sch.json"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidatorValidate compiles the schema once
+func BenchmarkValidatorValidate(b *testing.B) {
+	v, err := jsondatavalidator.NewValidator(strings.NewReader(testVMSchema), "sch.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := v.Validate(benchVMData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidatorValidateParallel(b *testing.B) {
+	v, err := jsondatavalidator.NewValidator(strings.NewReader(testVMSchema), "sch.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := v.Validate(benchVMData); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}